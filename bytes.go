@@ -0,0 +1,105 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+var byteType = reflect.TypeOf(byte(0))
+
+// dumpBytes dumps byte slices and arrays as a string when they contain text,
+// or as an hexdump otherwise.
+func (s *state) dumpBytes(value reflect.Value) {
+	typ := value.Type()
+	n := value.Len()
+
+	shown := n
+	if max := s.opts.maxBytes(); max >= 0 && n > max {
+		shown = max
+	}
+	data := make([]byte, shown)
+	for i := range data {
+		data[i] = byte(value.Index(i).Uint())
+	}
+
+	name := typ.String()
+	if typ.Name() == "" {
+		name = strings.Replace(name, "uint8", "byte", 1)
+	}
+
+	if text, ok := bytesAsText(data, shown < n); ok {
+		s.DumpString(text)
+		s.AddComment(name)
+		if shown < n {
+			s.AddComment(fmt.Sprintf("len=%d, truncated to %d bytes", n, len(text)))
+		}
+	} else {
+		if typ.Kind() == reflect.Slice {
+			s.AddComment(fmt.Sprintf("len=%d", n))
+		}
+		if shown < n {
+			s.AddComment(fmt.Sprintf("truncated to %d bytes", shown))
+		}
+		s.printfStyle("meta", "%s", name)
+		s.printf("{%s\n", s.DumpStructComments(value))
+		s.DepthDown()
+		scanner := bufio.NewScanner(strings.NewReader(hex.Dump(data)))
+		for scanner.Scan() {
+			s.Pad()
+			s.printf("%s\n", scanner.Text())
+		}
+		s.DepthUp()
+		s.Pad()
+		s.printf("}")
+	}
+
+	if s.depth == 0 {
+		s.printf(s.DumpStructComments(value))
+	}
+}
+
+// bytesAsText returns the data as a string if it is printable UTF-8 text.
+// When the data has been truncated, a trailing incomplete rune is ignored.
+func bytesAsText(data []byte, truncated bool) (string, bool) {
+	if truncated {
+		for i := 0; i < utf8.UTFMax-1 && len(data) > 0 && !utf8.Valid(data); i++ {
+			data = data[:len(data)-1]
+		}
+	}
+
+	if !utf8.Valid(data) {
+		return "", false
+	}
+
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && r != '\n' && r != '\r' && r != '\t' {
+			return "", false
+		}
+	}
+
+	return string(data), true
+}
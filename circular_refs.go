@@ -22,7 +22,6 @@ package dumper
 import (
 	"fmt"
 	"reflect"
-	"sort"
 )
 
 type visitedPointer struct {
@@ -76,12 +75,9 @@ func (pm *pointerMap) consider(v reflect.Value) {
 		pm.consider(v.Elem())
 
 	case reflect.Map:
-		keys := v.MapKeys()
-		sort.Sort(mapKeysSorter{
-			keys: keys,
-		})
-		for _, key := range keys {
-			pm.consider(v.MapIndex(key))
+		_, values := sortedMapEntries(v)
+		for _, value := range values {
+			pm.consider(value)
 		}
 
 	case reflect.Struct:
@@ -148,6 +144,15 @@ func (s *state) pointerRef(v reflect.Value) (string, bool) {
 	return "", false
 }
 
+func isNilValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+
+	return false
+}
+
 func isPointerValue(v reflect.Value) bool {
 	switch v.Kind() {
 	case reflect.Chan, reflect.Func, reflect.Map, reflect.Slice, reflect.Ptr, reflect.UnsafePointer:
@@ -0,0 +1,150 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"gopkg.in/yaml.v3"
+)
+
+// inputFormat guesses the format of the input from the file extension, or
+// from its first byte when reading from stdin
+func inputFormat(name string, r *bufio.Reader) string {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".msgpack", ".mpk":
+		return "msgpack"
+	}
+
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return "json"
+		}
+		switch c := b[0]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == '\n':
+			_, _ = r.ReadByte()
+		case c == '{' || c == '[' || c == '"':
+			return "json"
+		// fixmap, fixarray, array and map markers
+		case c >= 0x80 && c <= 0x9f, c >= 0xdc && c <= 0xdf:
+			return "msgpack"
+		default:
+			return "yaml"
+		}
+	}
+}
+
+// decode reads all the documents of the input
+func decode(format string, r io.Reader) ([]interface{}, error) {
+	var next func() (interface{}, error)
+
+	switch format {
+	case "json", "ndjson":
+		decoder := json.NewDecoder(r)
+		decoder.UseNumber()
+		next = func() (interface{}, error) {
+			var v interface{}
+			err := decoder.Decode(&v)
+			return v, err
+		}
+	case "yaml":
+		decoder := yaml.NewDecoder(r)
+		next = func() (interface{}, error) {
+			var v interface{}
+			err := decoder.Decode(&v)
+			return v, err
+		}
+	case "msgpack":
+		decoder := msgpack.NewDecoder(r)
+		next = decoder.DecodeInterfaceLoose
+	default:
+		return nil, errors.Errorf("unsupported input format %q", format)
+	}
+
+	var values []interface{}
+	for {
+		v, err := next()
+		if err == io.EOF {
+			return values, nil
+		}
+		if err != nil {
+			return values, errors.Wrapf(err, "invalid %s input", format)
+		}
+		values = append(values, normalize(v))
+	}
+}
+
+// normalize converts the numbers to int when possible, float64 otherwise,
+// so that they are dumped without their type
+func normalize(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+	case map[interface{}]interface{}:
+		for k, e := range v {
+			v[k] = normalize(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = normalize(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil && i >= math.MinInt && i <= math.MaxInt {
+			return int(i)
+		}
+		if f, err := v.Float64(); err == nil {
+			return f
+		}
+		return string(v)
+	default:
+		rv := reflect.ValueOf(v)
+		switch rv.Kind() {
+		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if i := rv.Int(); i >= math.MinInt && i <= math.MaxInt {
+				return int(i)
+			}
+		case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			if u := rv.Uint(); u <= math.MaxInt {
+				return int(u)
+			}
+		case reflect.Float32:
+			return rv.Float()
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,21 @@
+module github.com/symfony-cli/dumper/cmd/dumper
+
+go 1.17
+
+require (
+	github.com/pkg/errors v0.9.1
+	github.com/symfony-cli/dumper v0.0.0
+	github.com/vmihailenco/msgpack/v5 v5.3.5
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.1 // indirect
+	github.com/kr/pretty v0.3.1 // indirect
+	github.com/kr/text v0.2.0 // indirect
+	github.com/rogpeppe/go-internal v1.9.0 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+)
+
+replace github.com/symfony-cli/dumper => ../..
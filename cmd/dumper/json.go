@@ -0,0 +1,90 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/symfony-cli/dumper"
+)
+
+// limit applies the depth and items limits and the redaction of the options
+// to a decoded document, the same way the dumper does, for the JSON output
+func limit(opts dumper.Options, v interface{}, depth int) interface{} {
+	tooDeep := opts.MaxDepth > 0 && depth >= opts.MaxDepth
+
+	switch v := v.(type) {
+	case map[interface{}]interface{}:
+		// JSON objects only have string keys
+		m := make(map[string]interface{}, len(v))
+		for k, e := range v {
+			m[fmt.Sprint(k)] = e
+		}
+		return limit(opts, m, depth)
+
+	case map[string]interface{}:
+		if tooDeep {
+			return "{...}"
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		shown := len(keys)
+		if opts.MaxItems > 0 && shown > opts.MaxItems {
+			shown = opts.MaxItems
+		}
+
+		m := make(map[string]interface{}, shown+1)
+		for _, k := range keys[:shown] {
+			if opts.IsRedacted(k) {
+				m[k] = "<redacted>"
+			} else {
+				m[k] = limit(opts, v[k], depth+1)
+			}
+		}
+		if shown < len(keys) {
+			m["..."] = fmt.Sprintf("%d more", len(keys)-shown)
+		}
+		return m
+
+	case []interface{}:
+		if tooDeep {
+			return "[...]"
+		}
+		shown := len(v)
+		if opts.MaxItems > 0 && shown > opts.MaxItems {
+			shown = opts.MaxItems
+		}
+
+		l := make([]interface{}, 0, shown+1)
+		for _, e := range v[:shown] {
+			l = append(l, limit(opts, e, depth+1))
+		}
+		if shown < len(v) {
+			l = append(l, fmt.Sprintf("... %d more", len(v)-shown))
+		}
+		return l
+	}
+
+	return v
+}
@@ -0,0 +1,204 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Command dumper pretty-prints JSON, NDJSON, YAML, or msgpack documents read
+// from files or stdin.
+//
+//	curl -s https://api.example.com/users | dumper -depth 3 -redact token
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/symfony-cli/dumper"
+)
+
+const htmlHeader = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<style>
+%s</style>
+</head>
+<body>
+`
+
+const htmlFooter = `</body>
+</html>
+`
+
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*l = append(*l, v)
+		}
+	}
+
+	return nil
+}
+
+type config struct {
+	input  string
+	format string
+	color  string
+	opts   dumper.Options
+	files  []string
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintf(os.Stderr, "dumper: %s\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	cfg, err := parseFlags(args, stderr)
+	if err != nil {
+		return err
+	}
+
+	var values []interface{}
+	if len(cfg.files) == 0 {
+		cfg.files = []string{"-"}
+	}
+	for _, name := range cfg.files {
+		v, err := read(name, cfg.input, stdin)
+		if err != nil {
+			return err
+		}
+		values = append(values, v...)
+	}
+
+	return render(stdout, cfg, values)
+}
+
+func parseFlags(args []string, stderr io.Writer) (*config, error) {
+	cfg := &config{}
+	var redact listFlag
+
+	flags := flag.NewFlagSet("dumper", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: dumper [options] [file...]\n\nReads from stdin when no files are given, or for -.\n\nOptions:\n")
+		flags.PrintDefaults()
+	}
+	flags.StringVar(&cfg.input, "input", "auto", "input `format`: auto, json, ndjson, yaml, or msgpack")
+	flags.StringVar(&cfg.format, "format", "text", "output `format`: text, html, or json")
+	flags.StringVar(&cfg.color, "color", "auto", "colorize the text output: auto, always, or never")
+	flags.IntVar(&cfg.opts.MaxDepth, "depth", 0, "maximum nesting `depth`, 0 for no limit")
+	flags.IntVar(&cfg.opts.MaxItems, "items", 0, "maximum number of `items` per map or list, 0 for no limit")
+	flags.Var(&redact, "redact", "comma-separated `keys` whose values are hidden")
+	compact := flags.Bool("compact", false, "dump maps and lists on a single line when possible")
+	if err := flags.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg.opts.Multiline = !*compact
+	cfg.opts.Redact = redact
+	cfg.files = flags.Args()
+
+	return cfg, nil
+}
+
+func read(name, format string, stdin io.Reader) ([]interface{}, error) {
+	var r io.Reader = stdin
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	br := bufio.NewReader(r)
+	if format == "auto" {
+		format = inputFormat(name, br)
+	}
+
+	values, err := decode(format, br)
+	if err != nil && name != "-" {
+		err = errors.Wrap(err, name)
+	}
+
+	return values, err
+}
+
+func render(out io.Writer, cfg *config, values []interface{}) error {
+	switch cfg.format {
+	case "text":
+		color := cfg.color == "always"
+		if cfg.color == "auto" {
+			color = isTerminal(out)
+		}
+		for _, v := range values {
+			if color {
+				cfg.opts.FdumpColor(out, v)
+			} else {
+				cfg.opts.Fdump(out, v)
+			}
+		}
+
+	case "html":
+		fmt.Fprintf(out, htmlHeader, dumper.HTMLStylesheet)
+		for _, v := range values {
+			cfg.opts.FdumpHTML(out, v)
+		}
+		fmt.Fprint(out, htmlFooter)
+
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		encoder.SetEscapeHTML(false)
+		for _, v := range values {
+			if err := encoder.Encode(limit(cfg.opts, v, 0)); err != nil {
+				return errors.WithStack(err)
+			}
+		}
+
+	default:
+		return errors.Errorf("unsupported output format %q", cfg.format)
+	}
+
+	return nil
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	stat, err := f.Stat()
+
+	return err == nil && stat.Mode()&os.ModeCharDevice != 0
+}
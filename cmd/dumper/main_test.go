@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+type CommandSuite struct{}
+
+var _ = Suite(&CommandSuite{})
+
+func TestCommand(t *testing.T) { TestingT(t) }
+
+func runCommand(c *C, stdin string, args ...string) string {
+	stdout := &bytes.Buffer{}
+	c.Assert(run(args, strings.NewReader(stdin), stdout, &bytes.Buffer{}), IsNil)
+
+	return stdout.String()
+}
+
+func (cs *CommandSuite) TestJSON(c *C) {
+	c.Assert(runCommand(c, `{"name": "bob", "token": "s3cr3t", "ids": [1, 2, 3], "pi": 3.14}`, "-redact", "token", "-items", "2"), Equals, `map[string]interface {}{
+  "ids": []interface {}{ // len=3
+    1,
+    2,
+    /* 1 more */
+  },
+  "name": "bob",
+  /* 2 more */
+}
+`)
+
+	c.Assert(runCommand(c, "{\"a\": 1}\n{\"a\": [2]}\n", "-compact"), Equals, `map[string]interface {}{"a": 1,}
+map[string]interface {}{"a": []interface {}{2,},}
+`)
+}
+
+func (cs *CommandSuite) TestYAML(c *C) {
+	c.Assert(runCommand(c, "a: 1\n---\nb: [x, true]\n", "-compact"), Equals, `map[string]interface {}{"a": 1,}
+map[string]interface {}{"b": []interface {}{"x", true,},}
+`)
+}
+
+func (cs *CommandSuite) TestMsgpack(c *C) {
+	// {"a": 1, "b": [true, 1.5]}
+	input := string([]byte{0x82, 0xa1, 'a', 0x01, 0xa1, 'b', 0x92, 0xc3, 0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0})
+	c.Assert(runCommand(c, input, "-compact"), Equals, `map[string]interface {}{"a": 1, "b": []interface {}{true, 1.5,},}
+`)
+}
+
+func (cs *CommandSuite) TestFiles(c *C) {
+	dir := c.MkDir()
+	c.Assert(os.WriteFile(filepath.Join(dir, "config.yml"), []byte("port: 80\n"), 0o644), IsNil)
+	c.Assert(os.WriteFile(filepath.Join(dir, "config.json"), []byte(`{"port": 443}`), 0o644), IsNil)
+
+	c.Assert(runCommand(c, "", filepath.Join(dir, "config.yml"), filepath.Join(dir, "config.json")), Equals, `map[string]interface {}{
+  "port": 80,
+}
+map[string]interface {}{
+  "port": 443,
+}
+`)
+
+	err := run([]string{filepath.Join(dir, "missing.json")}, strings.NewReader(""), &bytes.Buffer{}, &bytes.Buffer{})
+	c.Assert(err, ErrorMatches, "open .*missing.json: no such file or directory")
+	err = run([]string{"-input", "json"}, strings.NewReader("{"), &bytes.Buffer{}, &bytes.Buffer{})
+	c.Assert(err, ErrorMatches, "invalid json input: unexpected EOF")
+}
+
+func (cs *CommandSuite) TestFormats(c *C) {
+	c.Assert(runCommand(c, `{"list": [1, {"a": 2}], "token": "s3cr3t", "z": 1}`, "-format", "json", "-depth", "2", "-items", "2", "-redact", "token"), Equals, `{
+  "...": "1 more",
+  "list": [
+    1,
+    "{...}"
+  ],
+  "token": "<redacted>"
+}
+`)
+
+	html := runCommand(c, `"<b>"`, "-format", "html")
+	c.Assert(strings.HasPrefix(html, "<!DOCTYPE html>"), Equals, true)
+	c.Assert(strings.Contains(html, `<pre class="dumper"><span class="dumper-const">&#34;</span><span class="dumper-str">&lt;b&gt;</span><span class="dumper-const">&#34;</span></pre>`), Equals, true)
+
+	err := run([]string{"-format", "xml"}, strings.NewReader("1"), &bytes.Buffer{}, &bytes.Buffer{})
+	c.Assert(err, ErrorMatches, `unsupported output format "xml"`)
+}
@@ -0,0 +1,69 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"io"
+)
+
+// compactWriter joins the lines of a dump: a line break and the indentation
+// following it are replaced by a space, or removed after an opening brace
+// and before a closing one.
+type compactWriter struct {
+	w        io.Writer
+	newLine  bool
+	lastByte byte
+}
+
+func (c *compactWriter) Write(p []byte) (int, error) {
+	buf := make([]byte, 0, len(p))
+	for _, b := range p {
+		switch {
+		case b == '\n':
+			c.newLine = true
+			continue
+		case b == ' ' && c.newLine:
+			continue
+		case c.newLine:
+			c.newLine = false
+			if b != '}' && c.lastByte != '{' && c.lastByte != 0 {
+				buf = append(buf, ' ')
+			}
+		}
+		buf = append(buf, b)
+		c.lastByte = b
+	}
+
+	if _, err := c.w.Write(buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// sdumpCompact dumps the values on a single line, without comments
+func (o Options) sdumpCompact(values ...interface{}) string {
+	o.singleLine, o.noComments = true, true
+	buf := &bytes.Buffer{}
+	fdump(buf, o, defaultStyles, values...)
+
+	return buf.String()
+}
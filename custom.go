@@ -26,6 +26,7 @@ import (
 	"io"
 	"reflect"
 	"strings"
+	"unsafe"
 )
 
 type State interface {
@@ -50,8 +51,9 @@ type State interface {
 }
 
 var (
-	dumpableType  = reflect.TypeOf((*Dumpable)(nil)).Elem()
-	customDumpers = make(map[reflect.Type]DumpFunc)
+	dumpableType           = reflect.TypeOf((*Dumpable)(nil)).Elem()
+	customDumpers          = make(map[reflect.Type]DumpFunc)
+	customInterfaceDumpers []interfaceDumper
 )
 
 // Dumpable is the interface for implementing custom dumper for your types.
@@ -96,20 +98,170 @@ func RegisterCustomDumper(v interface{}, f DumpFunc) {
 	customDumpers[val.Type()] = f
 }
 
-func (s *state) dumpCustomFn(v reflect.Value, fn DumpFunc) {
-	d := &dumpableFn{v: v, fn: fn}
+type interfaceDumper struct {
+	iface reflect.Type
+	fn    DumpFunc
+}
+
+// RegisterCustomInterfaceDumper registers a dumper for all the types
+// implementing an interface. The interface must be given as a nil pointer,
+// like (*error)(nil). Dumpers registered for a concrete type take precedence,
+// and interface dumpers are tried in their registration order.
+func RegisterCustomInterfaceDumper(iface interface{}, f DumpFunc) {
+	typ := reflect.TypeOf(iface)
+	if typ == nil || typ.Kind() != reflect.Ptr || typ.Elem().Kind() != reflect.Interface {
+		return
+	}
+
+	for i, d := range customInterfaceDumpers {
+		if d.iface == typ.Elem() {
+			customInterfaceDumpers[i].fn = f
+			return
+		}
+	}
+
+	customInterfaceDumpers = append(customInterfaceDumpers, interfaceDumper{iface: typ.Elem(), fn: f})
+}
+
+func UnregisterCustomInterfaceDumper(iface interface{}) {
+	typ := reflect.TypeOf(iface)
+	if typ == nil || typ.Kind() != reflect.Ptr {
+		return
+	}
+
+	for i, d := range customInterfaceDumpers {
+		if d.iface == typ.Elem() {
+			customInterfaceDumpers = append(customInterfaceDumpers[:i], customInterfaceDumpers[i+1:]...)
+			return
+		}
+	}
+}
+
+// interfaceDumperFor returns the first interface dumper matching the value.
+// Interface values are skipped as their dynamic value is dumped instead, as
+// well as pointers to values having a dumper registered for their type or
+// implementing the interface themselves.
+func interfaceDumperFor(v reflect.Value) DumpFunc {
+	if len(customInterfaceDumpers) == 0 || v.Kind() == reflect.Interface || !v.CanInterface() {
+		return nil
+	}
+
+	typ := v.Type()
+	// let the pointed value be dumped by its own dumper
+	if typ.Kind() == reflect.Ptr {
+		if _, ok := customDumpers[typ.Elem()]; ok {
+			return nil
+		}
+	}
+
+	for _, d := range customInterfaceDumpers {
+		if typ.Implements(d.iface) {
+			// the pointer is dumped as such, and the pointed value by the dumper
+			if typ.Kind() == reflect.Ptr && typ.Elem().Implements(d.iface) {
+				continue
+			}
+			return d.fn
+		}
+	}
+
+	return nil
+}
+
+// hasCustomDumper checks whether values of the type are dumped by a
+// Dumpable implementation or by a registered custom dumper, which might hide
+// part of them, like the private keys
+func hasCustomDumper(typ reflect.Type) bool {
+	if typ.Implements(dumpableType) {
+		return true
+	}
+
+	if _, ok := customDumpers[typ]; ok {
+		return true
+	}
+
+	for _, d := range customInterfaceDumpers {
+		if typ.Implements(d.iface) {
+			return true
+		}
+	}
 
-	s.dumpCustom(v, d)
+	return false
+}
+
+// exposed returns the value of an unexported struct field as if it was
+// exported, so that custom dumpers can call Interface() on it. Reflection
+// forbids it, hence the access through the address of the field.
+func exposed(v reflect.Value) reflect.Value {
+	if v.CanInterface() || !v.CanAddr() {
+		return v
+	}
+
+	return reflect.NewAt(v.Type(), unsafe.Pointer(v.UnsafeAddr())).Elem()
+}
+
+// customDumperFor returns the custom dumper to use for the value, if any.
+func (s *state) customDumperFor(v reflect.Value) Dumpable {
+	typ := v.Type()
+	if typ.Implements(dumpableType) {
+		return v.Interface().(Dumpable)
+	}
+
+	// nil values are dumped as such, not through dumpers registered for their type
+	if isNilValue(v) {
+		return nil
+	}
+
+	if fn, ok := customDumpers[typ]; ok {
+		return &dumpableFn{v: v, fn: fn}
+	}
+
+	if fn := interfaceDumperFor(v); fn != nil {
+		return &dumpableFn{v: v, fn: fn}
+	}
+
+	if d := s.methodDumperFor(v); d != nil {
+		return d
+	}
+
+	return nil
 }
 
 func (s *state) dumpCustom(v reflect.Value, vv Dumpable) {
+	w, depth, forceNewLines := s.w, s.depth, s.forceNewLines
 	previousComments := s.ResetComments()
 	s.DepthDown()
 
+	raw, panicked := s.safeDump(vv)
+	if panicked != nil {
+		// Restore the state as it was before calling the custom dumper and
+		// fall back to the default dump of the value
+		s.w, s.depth, s.forceNewLines = w, depth, forceNewLines
+		s.ResetComments()
+		for _, comment := range previousComments {
+			s.AddComment(comment)
+		}
+		s.AddComment(fmt.Sprintf("custom dumper panicked: %v", panicked))
+		s.dumpDefault(v)
+		return
+	}
+
+	// Custom dumpers writing a single line are displayed inline, without
+	// the type name; they are responsible for adding the relevant comments
+	if raw != "" && !strings.Contains(raw, "\n") {
+		comments := s.ResetComments()
+		for _, comment := range append(previousComments, comments...) {
+			s.AddComment(comment)
+		}
+		s.DepthUp()
+		s.print(raw)
+		if s.depth == 0 {
+			s.print(s.DumpStructComments(v))
+		}
+		return
+	}
+
 	str := s.WithTempBuffer(func(buf *bytes.Buffer) {
-		scanner := bufio.NewScanner(strings.NewReader(s.WithTempBuffer(func(buf *bytes.Buffer) {
-			vv.Dump(s)
-		})))
+		scanner := bufio.NewScanner(strings.NewReader(raw))
 
 		for scanner.Scan() {
 			line := strings.TrimRight(scanner.Text(), " \n\t")
@@ -130,9 +282,49 @@ func (s *state) dumpCustom(v reflect.Value, vv Dumpable) {
 		s.AddComment(comment)
 	}
 
-	s.DumpStructType(v.Type())
+	if v.Kind() == reflect.Ptr {
+		s.AddComment(s.pointerComment(v))
+		s.printf("&")
+		s.DumpStructType(v.Type().Elem())
+	} else {
+		s.DumpStructType(v.Type())
+	}
 	s.printf("{%s\n%s", s.DumpStructComments(v), str)
 	s.DepthUp()
 	s.Pad()
 	s.printf("}")
 }
+
+// safeDump runs the custom dumper into a temporary buffer and recovers from
+// any panic it might raise.
+func (s *state) safeDump(vv Dumpable) (str string, panicked interface{}) {
+	defer func() {
+		panicked = recover()
+	}()
+
+	str = s.WithTempBuffer(func(buf *bytes.Buffer) {
+		vv.Dump(s)
+	})
+
+	return
+}
+
+// DumpStringer is a DumpFunc displaying the value returned by the String()
+// method. Register it for the types implementing fmt.Stringer that are better
+// dumped that way:
+//
+//	RegisterCustomDumper(uuid.UUID{}, DumpStringer)
+func DumpStringer(s State, v reflect.Value) {
+	stringer, ok := v.Interface().(fmt.Stringer)
+	if !ok && v.Kind() != reflect.Ptr {
+		stringer, ok = addressable(v).Interface().(fmt.Stringer)
+	}
+	if !ok {
+		s.AddComment(fmt.Sprintf("%s does not implement fmt.Stringer", v.Type()))
+		_, _ = s.Write([]byte("<invalid>"))
+		return
+	}
+
+	s.AddComment(v.Type().String())
+	s.DumpString(stringer.String())
+}
@@ -0,0 +1,68 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+)
+
+func init() {
+	RegisterCustomDumper(big.Int{}, dumpBigInt)
+	RegisterCustomDumper((*big.Int)(nil), dumpBigInt)
+	RegisterCustomDumper(big.Float{}, dumpBigFloat)
+	RegisterCustomDumper((*big.Float)(nil), dumpBigFloat)
+	RegisterCustomDumper(big.Rat{}, dumpBigRat)
+	RegisterCustomDumper((*big.Rat)(nil), dumpBigRat)
+}
+
+// addressable returns a pointer to the value, copying it when needed
+func addressable(v reflect.Value) reflect.Value {
+	if v.Kind() == reflect.Ptr {
+		return v
+	}
+
+	p := reflect.New(v.Type())
+	p.Elem().Set(v)
+
+	return p
+}
+
+func dumpBigInt(s State, v reflect.Value) {
+	i := addressable(v).Interface().(*big.Int)
+	s.AddComment(v.Type().String())
+	s.DumpScalar(i.String(), v.Type(), false)
+}
+
+func dumpBigFloat(s State, v reflect.Value) {
+	f := addressable(v).Interface().(*big.Float)
+	s.AddComment(fmt.Sprintf("%s, prec=%d, mode=%s", v.Type(), f.Prec(), f.Mode()))
+	s.DumpScalar(f.Text('f', -1), v.Type(), false)
+}
+
+func dumpBigRat(s State, v reflect.Value) {
+	r := addressable(v).Interface().(*big.Rat)
+	s.AddComment(v.Type().String())
+	if !r.IsInt() {
+		s.AddComment(fmt.Sprintf("= %s", r.FloatString(10)))
+	}
+	s.DumpScalar(r.RatString(), v.Type(), false)
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"fmt"
+	"reflect"
+	"runtime"
+
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterCustomInterfaceDumper((*error)(nil), dumpError)
+}
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+type multiUnwrapper interface {
+	Unwrap() []error
+}
+
+func dumpError(s State, v reflect.Value) {
+	err := v.Interface().(error)
+	// pointers to errors are dumped as pointers, but the methods of the
+	// pointer, like Unwrap(), are still needed
+	if v.Kind() != reflect.Ptr && v.CanAddr() {
+		err = v.Addr().Interface().(error)
+	}
+
+	// an Unwrap() chain looping back to an error being dumped would never end
+	ss, _ := s.(*state)
+	if ss != nil {
+		ss.errorChain = append(ss.errorChain, err)
+		defer func() { ss.errorChain = ss.errorChain[:len(ss.errorChain)-1] }()
+	}
+
+	s.DumpStructField("Error", reflect.ValueOf(err.Error()))
+
+	if st, ok := err.(stackTracer); ok {
+		dumpErrorStack(s, st.StackTrace())
+	}
+
+	var causes []error
+	if e, ok := err.(multiUnwrapper); ok {
+		causes = e.Unwrap()
+		dumpErrorCauses(s, causes)
+	} else if cause := errors.Unwrap(err); cause != nil {
+		causes = []error{cause}
+		if ss != nil && ss.isDumpingError(cause) {
+			dumpErrorCycle(s, "Cause")
+		} else {
+			s.DumpStructField("Cause", reflect.ValueOf(cause))
+		}
+	}
+
+	// like for method fallbacks, the fields of struct errors are dumped
+	// too, except the ones holding the causes already dumped
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if ss != nil && v.Kind() == reflect.Struct {
+		ss.dumpStructFields(v, nil, func(f reflect.Value) bool {
+			return isOneOfErrors(f, causes)
+		})
+	}
+}
+
+// isDumpingError checks whether the error is one of the errors being dumped
+func (s *state) isDumpingError(err error) bool {
+	for _, e := range s.errorChain {
+		if sameError(e, err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sameError compares errors without panicking on uncomparable types
+func sameError(a, b error) bool {
+	ta := reflect.TypeOf(a)
+	if ta != reflect.TypeOf(b) || !ta.Comparable() {
+		return false
+	}
+
+	return a == b
+}
+
+// isOneOfErrors checks whether the field holds one of the errors; pointers
+// are compared by address as unexported fields cannot be read as interfaces
+func isOneOfErrors(v reflect.Value, errs []error) bool {
+	if v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return false
+		}
+		v = v.Elem()
+	}
+
+	for _, err := range errs {
+		ev := reflect.ValueOf(err)
+		if !ev.IsValid() || ev.Type() != v.Type() {
+			continue
+		}
+		if v.Kind() == reflect.Ptr {
+			if v.Pointer() == ev.Pointer() {
+				return true
+			}
+		} else if v.CanInterface() && sameError(v.Interface().(error), err) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func dumpErrorCycle(s State, name string) {
+	s.Pad()
+	fmt.Fprintf(s, "%s: ", name)
+	styled(s, "ref", "<cycle>")
+	_, _ = s.Write([]byte(",\n"))
+}
+
+func dumpErrorCauses(s State, causes []error) {
+	s.Pad()
+	_, _ = s.Write([]byte("Causes: {\n"))
+	s.DepthDown()
+	for _, cause := range causes {
+		s.Pad()
+		if cause == nil {
+			_, _ = s.Write([]byte("nil"))
+		} else if ss, ok := s.(*state); ok && ss.isDumpingError(cause) {
+			styled(s, "ref", "<cycle>")
+		} else {
+			s.Dump(cause)
+		}
+		_, _ = s.Write([]byte(",\n"))
+	}
+	s.DepthUp()
+	s.Pad()
+	_, _ = s.Write([]byte("},\n"))
+}
+
+func dumpErrorStack(s State, stack errors.StackTrace) {
+	s.Pad()
+	_, _ = s.Write([]byte("Stack: {\n"))
+	s.DepthDown()
+	for _, f := range stack {
+		pc := uintptr(f) - 1
+		name, file, line := "unknown", "unknown", 0
+		if fn := runtime.FuncForPC(pc); fn != nil {
+			name = fn.Name()
+			file, line = fn.FileLine(pc)
+		}
+
+		s.Pad()
+		fmt.Fprintf(s, "%s %s:%d,\n", name, file, line)
+	}
+	s.DepthUp()
+	s.Pad()
+	_, _ = s.Write([]byte("},\n"))
+}
@@ -21,7 +21,9 @@ package dumper
 
 import (
 	"bytes"
+	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"reflect"
 	"sort"
@@ -30,6 +32,8 @@ import (
 	"github.com/pkg/errors"
 )
 
+const redactedValue = "<redacted>"
+
 func init() {
 	RegisterCustomDumper(http.Response{}, dumpHttpResponse)
 	RegisterCustomDumper(http.Request{}, dumpHttpRequest)
@@ -39,14 +43,27 @@ func dumpHttpHeaders(s State, headers http.Header) {
 	s.Pad()
 	_, _ = s.Write([]byte("Headers: {\n"))
 	s.DepthDown()
-	keys := make([]string, len(headers))
+	dumpHttpHeaderLines(s, headers)
+	s.DepthUp()
+	s.Pad()
+	_, _ = s.Write([]byte("},\n"))
+}
+
+// dumpHttpHeaderLines dumps one line per header value, sorted by name
+func dumpHttpHeaderLines(s State, headers http.Header) {
+	keys := make([]string, 0, len(headers))
 	for key := range headers {
 		keys = append(keys, key)
 	}
 	sort.Strings(keys)
 
+	opts := optionsOf(s)
 	for _, key := range keys {
+		redacted := opts.IsRedacted(key)
 		for _, v := range headers[key] {
+			if redacted {
+				v = redactedValue
+			}
 			s.Pad()
 			s.DumpString(key)
 			_, _ = s.Write([]byte(": "))
@@ -54,9 +71,6 @@ func dumpHttpHeaders(s State, headers http.Header) {
 			_, _ = s.Write([]byte(",\n"))
 		}
 	}
-	s.DepthUp()
-	s.Pad()
-	_, _ = s.Write([]byte("},\n"))
 }
 
 func dumpHttpRequest(s State, v reflect.Value) {
@@ -68,21 +82,7 @@ func dumpHttpRequest(s State, v reflect.Value) {
 	}
 
 	dumpHttpHeaders(s, req.Header)
-
-	if req.ContentLength == 0 {
-		s.DumpStructField("Body", reflect.ValueOf(""))
-	} else if ct := req.Header.Get("Content-Type"); isContentTypeTextSafe(ct) {
-		Body, body, err := copyBody(req.Body)
-		if err != nil {
-			return
-		}
-
-		v.FieldByName("Body").Set(reflect.ValueOf(Body))
-		s.DumpStructField("Body", reflect.ValueOf(body))
-	} else {
-		s.DumpStructField("Body", reflect.ValueOf("<BINARY>"))
-	}
-
+	dumpHttpBody(s, v, req.ContentLength, req.Header)
 }
 
 func dumpHttpResponse(s State, v reflect.Value) {
@@ -94,72 +94,143 @@ func dumpHttpResponse(s State, v reflect.Value) {
 
 	dumpHttpHeaders(s, resp.Header)
 
-	if chunked(resp.TransferEncoding) && resp.ContentLength == -1 {
-		s.AddComment("streamed")
-		s.DumpStructField("Body", reflect.ValueOf(nil))
+	if _, ok := resp.Body.(*streamedBody); ok || isStreamed(resp) {
+		s.DumpStructField("Body", reflect.ValueOf("<STREAMED>"))
 		return
 	}
 
-	if resp.ContentLength == 0 {
+	dumpHttpBody(s, v, resp.ContentLength, resp.Header)
+}
+
+// dumpHttpBody dumps the beginning of the body of a request or a response.
+// As reading the body consumes it, it is only read when a reader yielding the
+// whole body can be put back, which is the case when the request or the
+// response is dumped through a pointer.
+func dumpHttpBody(s State, v reflect.Value, contentLength int64, header http.Header) {
+	field := v.FieldByName("Body")
+	body, _ := field.Interface().(io.ReadCloser)
+
+	if contentLength == 0 || body == nil || body == http.NoBody {
 		s.DumpStructField("Body", reflect.ValueOf(""))
-	} else if ct := resp.Header.Get("Content-Type"); isContentTypeTextSafe(ct) {
-		Body, body, err := copyBody(resp.Body)
+		return
+	}
+
+	mediaType, params := parseMediaType(header.Get("Content-Type"))
+	if !isMediaTypeTextSafe(mediaType) && !strings.HasPrefix(mediaType, "multipart/") {
+		s.DumpStructField("Body", reflect.ValueOf("<BINARY>"))
+		return
+	}
+
+	if !field.CanSet() {
+		s.AddComment("dump a pointer to read the body")
+		s.DumpStructField("Body", reflect.ValueOf("<UNREAD>"))
+		return
+	}
+
+	max := optionsOf(s).maxBytes()
+	peeked, truncated, restored, err := peekBody(body, max)
+	field.Set(reflect.ValueOf(restored))
+	// bodies might have been captured up to the limit before, like the
+	// ones of the responses sent through Middleware
+	if contentLength > int64(len(peeked)) {
+		truncated = true
+	}
+	if err != nil {
+		s.AddComment(fmt.Sprintf("failed to read the body: %s", err))
+	}
+	if truncated {
+		s.AddComment(fmt.Sprintf("truncated to %d bytes", len(peeked)))
+	}
+
+	if encoding := header.Get("Content-Encoding"); encoding != "" {
+		decoded, err := decodeBody(peeked, encoding, max)
 		if err != nil {
-			s.DumpStructField("Body", reflect.ValueOf(nil))
+			s.AddComment(fmt.Sprintf("failed to decode the %s body: %s", encoding, err))
+		}
+		if len(decoded) == 0 && err != nil {
+			s.DumpStructField("Body", reflect.ValueOf("<BINARY>"))
 			return
 		}
+		s.AddComment(fmt.Sprintf("%s decoded", encoding))
+		peeked = decoded
+	}
 
-		v.FieldByName("Body").Set(reflect.ValueOf(Body))
-		s.DumpStructField("Body", reflect.ValueOf(body))
-	} else {
-		s.DumpStructField("Body", reflect.ValueOf("<BINARY>"))
+	dumpHttpBodyContent(s, mediaType, params, peeked)
+}
+
+// parseMediaType returns the lowercased media type and its parameters, even
+// when the Content-Type is not valid
+func parseMediaType(ct string) (string, map[string]string) {
+	mediaType, params, err := mime.ParseMediaType(ct)
+	if err != nil {
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(ct, ";")[0]))
 	}
+
+	return mediaType, params
 }
 
 func isContentTypeTextSafe(ct string) bool {
-	if strings.HasPrefix(ct, "text/") {
-		return true
-	}
+	mediaType, _ := parseMediaType(ct)
 
-	if strings.HasSuffix(ct, "/json") {
-		return true
-	}
+	return isMediaTypeTextSafe(mediaType)
+}
 
-	if strings.HasSuffix(ct, "/xml") {
+func isMediaTypeTextSafe(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
 		return true
 	}
 
-	if ct == "application/x-www-form-urlencoded" {
+	if isJSONMediaType(mediaType) || isXMLMediaType(mediaType) {
 		return true
 	}
 
-	if ct == "application/ld+json" || ct == "application/xhtml+xml" {
-		return true
-	}
+	return mediaType == "application/x-www-form-urlencoded"
+}
+
+func isJSONMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "/json") || strings.HasSuffix(mediaType, "+json")
+}
 
-	return false
+func isXMLMediaType(mediaType string) bool {
+	return strings.HasSuffix(mediaType, "/xml") || strings.HasSuffix(mediaType, "+xml")
 }
 
 // Checks whether chunked is part of the encodings stack
 func chunked(te []string) bool { return len(te) > 0 && te[0] == "chunked" }
 
-// copyBody reads all of b to memory and then returns two equivalent
-// ReadClosers yielding the same bytes.
-//
-// It returns an error if the initial slurp of all bytes fails. It does not attempt
-// to make the returned ReadClosers have identical error-matching behavior.
-func copyBody(b io.ReadCloser) (io.ReadCloser, interface{}, error) {
-	if b == http.NoBody || b == nil {
-		// No copying needed. Preserve the magic sentinel meaning of NoBody.
-		return http.NoBody, http.NoBody, nil
+// isStreamed checks whether the response body is streamed, in which case it
+// must not be read
+func isStreamed(resp http.Response) bool {
+	if chunked(resp.TransferEncoding) && resp.ContentLength == -1 {
+		return true
 	}
-	var buf bytes.Buffer
-	if _, err := buf.ReadFrom(b); err != nil {
-		return b, "<invalid>", errors.Wrap(err, "failed to read from body")
+
+	return strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream")
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// peekBody reads at most max bytes from the body, or all of it when max is
+// negative. It returns them, whether the body is longer, and a ReadCloser
+// yielding the whole body, including the bytes already read.
+func peekBody(b io.ReadCloser, max int) ([]byte, bool, io.ReadCloser, error) {
+	var r io.Reader = b
+	if max >= 0 {
+		r = io.LimitReader(b, int64(max)+1)
 	}
-	if err := b.Close(); err != nil {
-		return b, "<invalid>", errors.Wrap(err, "failed to close body")
+
+	peeked, err := io.ReadAll(r)
+	restored := &readCloser{Reader: io.MultiReader(bytes.NewReader(peeked), b), Closer: b}
+	if err != nil {
+		err = errors.Wrap(err, "failed to read from body")
+	}
+
+	if max >= 0 && len(peeked) > max {
+		return peeked[:max], true, restored, err
 	}
 
-	return io.NopCloser(&buf), buf.String(), nil
+	return peeked, false, restored, err
 }
@@ -0,0 +1,265 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+	"github.com/pkg/errors"
+)
+
+func init() {
+	RegisterCustomDumper(json.Number(""), dumpJSONNumber)
+}
+
+// decodeBody decodes at most max bytes of a body compressed with the given
+// Content-Encoding. As the body might have been truncated, what could be
+// decoded is returned along with the error.
+func decodeBody(data []byte, encoding string, max int) ([]byte, error) {
+	var r io.Reader
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "", "identity":
+		return data, nil
+	case "gzip", "x-gzip":
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		r = zr
+	case "deflate":
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		r = zr
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(data))
+	default:
+		return nil, errors.New("unsupported encoding")
+	}
+
+	if max >= 0 {
+		r = io.LimitReader(r, int64(max))
+	}
+	decoded, err := io.ReadAll(r)
+
+	return decoded, errors.WithStack(err)
+}
+
+// dumpHttpBodyContent dumps a body according to its media type: JSON is
+// decoded, forms are displayed as url.Values, multipart bodies part by part,
+// and XML is indented.
+func dumpHttpBodyContent(s State, mediaType string, params map[string]string, data []byte) {
+	switch {
+	case isJSONMediaType(mediaType):
+		decoded, err := decodeJSON(data)
+		if err != nil {
+			s.AddComment(fmt.Sprintf("invalid JSON: %s", err))
+			break
+		}
+		previous := s.ForceNewLines(true)
+		s.DumpStructField("Body", reflect.ValueOf(&decoded).Elem())
+		s.ForceNewLines(previous)
+		return
+
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := url.ParseQuery(string(data))
+		if err != nil {
+			s.AddComment(fmt.Sprintf("invalid form: %s", err))
+			break
+		}
+		s.DumpStructField("Body", reflect.ValueOf(values))
+		return
+
+	case strings.HasPrefix(mediaType, "multipart/"):
+		if boundary := params["boundary"]; boundary != "" {
+			dumpMultipartBody(s, data, boundary)
+			return
+		}
+		s.AddComment("missing multipart boundary")
+
+	case isXMLMediaType(mediaType):
+		if indented, err := indentXML(data); err == nil {
+			data = indented
+		}
+	}
+
+	s.DumpStructField("Body", reflect.ValueOf(string(data)))
+}
+
+// decodeJSON decodes a JSON document, keeping numbers as they are written:
+// integers become ints when they fit, other numbers stay json.Number
+func decodeJSON(data []byte) (interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var decoded interface{}
+	if err := decoder.Decode(&decoded); err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected data after the document")
+	}
+
+	return jsonNumbers(decoded), nil
+}
+
+func jsonNumbers(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = jsonNumbers(e)
+		}
+	case []interface{}:
+		for i, e := range v {
+			v[i] = jsonNumbers(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil && i >= math.MinInt && i <= math.MaxInt {
+			return int(i)
+		}
+	}
+
+	return v
+}
+
+// dumpJSONNumber dumps numbers as written in the JSON document
+func dumpJSONNumber(s State, v reflect.Value) {
+	s.AddComment(v.Type().String())
+	s.DumpScalar(v.String(), v.Type(), false)
+}
+
+func dumpMultipartBody(s State, data []byte, boundary string) {
+	s.Pad()
+	_, _ = s.Write([]byte("Body: {"))
+	if comments := s.ResetComments(); len(comments) > 0 {
+		writeComments(s, comments...)
+	}
+	_, _ = s.Write([]byte("\n"))
+	s.DepthDown()
+
+	r := multipart.NewReader(bytes.NewReader(data), boundary)
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			s.DumpStructField("Error", reflect.ValueOf(err.Error()))
+			break
+		}
+
+		content, err := io.ReadAll(part)
+		s.Pad()
+		_, _ = s.Write([]byte("{\n"))
+		s.DepthDown()
+		dumpHttpHeaders(s, http.Header(part.Header))
+		if err != nil {
+			s.AddComment(fmt.Sprintf("failed to read the part: %s", err))
+		}
+		s.DumpStructField("Size", reflect.ValueOf(len(content)))
+		// form fields have no Content-Type
+		if ct := part.Header.Get("Content-Type"); (ct == "" && part.FileName() == "") || isContentTypeTextSafe(ct) {
+			s.DumpStructField("Content", reflect.ValueOf(string(content)))
+		} else {
+			s.DumpStructField("Content", reflect.ValueOf("<BINARY>"))
+		}
+		s.DepthUp()
+		s.Pad()
+		_, _ = s.Write([]byte("},\n"))
+	}
+
+	s.DepthUp()
+	s.Pad()
+	_, _ = s.Write([]byte("},\n"))
+}
+
+// prefixedXMLToken moves the namespace prefixes of raw tokens into the local
+// names, as the encoder would otherwise take them for namespace URLs
+func prefixedXMLToken(token xml.Token) xml.Token {
+	switch t := token.(type) {
+	case xml.StartElement:
+		t.Name = prefixedXMLName(t.Name)
+		attrs := make([]xml.Attr, len(t.Attr))
+		for i, attr := range t.Attr {
+			attrs[i] = xml.Attr{Name: prefixedXMLName(attr.Name), Value: attr.Value}
+		}
+		t.Attr = attrs
+		return t
+	case xml.EndElement:
+		t.Name = prefixedXMLName(t.Name)
+		return t
+	}
+
+	return token
+}
+
+func prefixedXMLName(name xml.Name) xml.Name {
+	if name.Space == "" {
+		return name
+	}
+
+	return xml.Name{Local: name.Space + ":" + name.Local}
+}
+
+// indentXML re-encodes the XML document with indentation
+func indentXML(data []byte) ([]byte, error) {
+	buf := &bytes.Buffer{}
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	encoder := xml.NewEncoder(buf)
+	encoder.Indent("", "  ")
+
+	for {
+		// raw tokens keep the namespace prefixes as written
+		token, err := decoder.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		// whitespace between elements is replaced by the indentation
+		if cd, ok := token.(xml.CharData); ok && len(bytes.TrimSpace(cd)) == 0 {
+			continue
+		}
+		if err := encoder.EncodeToken(prefixedXMLToken(token)); err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+
+	if err := encoder.Flush(); err != nil {
+		return nil, errors.WithStack(err)
+	}
+
+	return buf.Bytes(), nil
+}
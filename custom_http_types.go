@@ -0,0 +1,191 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"sort"
+	"time"
+)
+
+func init() {
+	RegisterCustomDumper(http.Header{}, dumpHttpHeader)
+	RegisterCustomDumper(http.Cookie{}, dumpHttpCookie)
+	RegisterCustomDumper(http.Client{}, dumpHttpClient)
+	RegisterCustomDumper(http.Server{}, dumpHttpServer)
+	RegisterCustomDumper(multipart.Form{}, dumpMultipartForm)
+}
+
+var sameSiteModes = map[http.SameSite]string{
+	http.SameSiteDefaultMode: "Default",
+	http.SameSiteLaxMode:     "Lax",
+	http.SameSiteStrictMode:  "Strict",
+	http.SameSiteNoneMode:    "None",
+}
+
+func dumpHttpHeader(s State, v reflect.Value) {
+	dumpHttpHeaderLines(s, v.Interface().(http.Header))
+}
+
+// dumpHttpCookie only dumps the attributes which are set
+func dumpHttpCookie(s State, v reflect.Value) {
+	cookie := v.Interface().(http.Cookie)
+
+	value := cookie.Value
+	if optionsOf(s).IsRedacted("Cookie") {
+		value = redactedValue
+	}
+	s.DumpStructField("Name", reflect.ValueOf(cookie.Name))
+	s.DumpStructField("Value", reflect.ValueOf(value))
+
+	if cookie.Path != "" {
+		s.DumpStructField("Path", reflect.ValueOf(cookie.Path))
+	}
+	if cookie.Domain != "" {
+		s.DumpStructField("Domain", reflect.ValueOf(cookie.Domain))
+	}
+	if !cookie.Expires.IsZero() {
+		s.DumpStructField("Expires", reflect.ValueOf(cookie.Expires))
+	}
+	if cookie.MaxAge > 0 {
+		s.DumpStructField("MaxAge", reflect.ValueOf(time.Duration(cookie.MaxAge)*time.Second))
+	} else if cookie.MaxAge < 0 {
+		s.AddComment("deleted")
+		s.DumpStructField("MaxAge", reflect.ValueOf(cookie.MaxAge))
+	}
+	if cookie.Secure {
+		s.DumpStructField("Secure", reflect.ValueOf(true))
+	}
+	if cookie.HttpOnly {
+		s.DumpStructField("HttpOnly", reflect.ValueOf(true))
+	}
+	if cookie.SameSite != 0 {
+		mode, ok := sameSiteModes[cookie.SameSite]
+		if !ok {
+			mode = fmt.Sprintf("SameSite(%d)", cookie.SameSite)
+		}
+		s.DumpStructField("SameSite", reflect.ValueOf(mode))
+	}
+}
+
+// typeName returns the name of the dynamic type of v, or the fallback when
+// v is nil
+func typeName(v interface{}, fallback string) string {
+	if v == nil {
+		return fallback
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+func dumpHttpClient(s State, v reflect.Value) {
+	client := v.Interface().(http.Client)
+
+	s.DumpStructField("Transport", reflect.ValueOf(typeName(client.Transport, "http.DefaultTransport")))
+	if client.Jar != nil {
+		s.DumpStructField("Jar", reflect.ValueOf(typeName(client.Jar, "")))
+	}
+	if client.CheckRedirect != nil {
+		s.DumpStructField("CheckRedirect", reflect.ValueOf(typeName(client.CheckRedirect, "")))
+	}
+	if client.Timeout == 0 {
+		s.AddComment("no timeout")
+	}
+	s.DumpStructField("Timeout", reflect.ValueOf(client.Timeout))
+}
+
+// dumpHttpServer reads the fields through reflection as http.Server holds
+// locks which must not be copied
+func dumpHttpServer(s State, v reflect.Value) {
+	addr := v.FieldByName("Addr").String()
+	if addr == "" {
+		s.AddComment("listens on :http or :https")
+	}
+	s.DumpStructField("Addr", reflect.ValueOf(addr))
+
+	var handler interface{}
+	if h := v.FieldByName("Handler"); !h.IsNil() {
+		handler = h.Elem().Interface()
+	}
+	s.DumpStructField("Handler", reflect.ValueOf(typeName(handler, "http.DefaultServeMux")))
+
+	s.DumpStructField("TLS", reflect.ValueOf(!v.FieldByName("TLSConfig").IsNil()))
+
+	for _, f := range []string{"ReadTimeout", "ReadHeaderTimeout", "WriteTimeout", "IdleTimeout", "MaxHeaderBytes"} {
+		if field := v.FieldByName(f); !field.IsZero() {
+			s.DumpStructField(f, field)
+		}
+	}
+}
+
+func dumpMultipartForm(s State, v reflect.Value) {
+	form := v.Interface().(multipart.Form)
+
+	s.Pad()
+	_, _ = s.Write([]byte("Value: {\n"))
+	s.DepthDown()
+	keys := make([]string, 0, len(form.Value))
+	for key := range form.Value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, value := range form.Value[key] {
+			s.Pad()
+			s.DumpString(key)
+			_, _ = s.Write([]byte(": "))
+			s.DumpString(value)
+			_, _ = s.Write([]byte(",\n"))
+		}
+	}
+	s.DepthUp()
+	s.Pad()
+	_, _ = s.Write([]byte("},\n"))
+
+	s.Pad()
+	_, _ = s.Write([]byte("File: {\n"))
+	s.DepthDown()
+	keys = keys[:0]
+	for key := range form.File {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		for _, file := range form.File[key] {
+			comments := []string{fmt.Sprintf("%d bytes", file.Size)}
+			if ct := file.Header.Get("Content-Type"); ct != "" {
+				comments = append([]string{ct}, comments...)
+			}
+			s.Pad()
+			s.DumpString(key)
+			_, _ = s.Write([]byte(": "))
+			s.DumpString(file.Filename)
+			_, _ = s.Write([]byte(","))
+			writeComments(s, comments...)
+			_, _ = s.Write([]byte("\n"))
+		}
+	}
+	s.DepthUp()
+	s.Pad()
+	_, _ = s.Write([]byte("},\n"))
+}
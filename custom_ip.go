@@ -26,9 +26,39 @@ import (
 
 func init() {
 	RegisterCustomDumper(net.IP{}, dumpNetIp)
+	RegisterCustomDumper(net.IPNet{}, dumpNetIpNet)
+	RegisterCustomDumper(net.HardwareAddr{}, dumpNetHardwareAddr)
+	RegisterCustomDumper(net.TCPAddr{}, dumpNetTCPAddr)
+	RegisterCustomDumper(net.UDPAddr{}, dumpNetUDPAddr)
+}
+
+// dumpAsString dumps a value inline as a string, its type as a comment
+func dumpAsString(s State, v reflect.Value, str string) {
+	s.AddComment(v.Type().String())
+	s.DumpString(str)
 }
 
 func dumpNetIp(s State, v reflect.Value) {
 	ip := v.Interface().(net.IP)
-	s.DumpString(ip.String())
+	dumpAsString(s, v, ip.String())
+}
+
+func dumpNetIpNet(s State, v reflect.Value) {
+	ipNet := v.Interface().(net.IPNet)
+	dumpAsString(s, v, ipNet.String())
+}
+
+func dumpNetHardwareAddr(s State, v reflect.Value) {
+	addr := v.Interface().(net.HardwareAddr)
+	dumpAsString(s, v, addr.String())
+}
+
+func dumpNetTCPAddr(s State, v reflect.Value) {
+	addr := v.Interface().(net.TCPAddr)
+	dumpAsString(s, v, addr.String())
+}
+
+func dumpNetUDPAddr(s State, v reflect.Value) {
+	addr := v.Interface().(net.UDPAddr)
+	dumpAsString(s, v, addr.String())
 }
@@ -0,0 +1,48 @@
+//go:build go1.18
+
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"net/netip"
+	"reflect"
+)
+
+func init() {
+	RegisterCustomDumper(netip.Addr{}, dumpNetipAddr)
+	RegisterCustomDumper(netip.Prefix{}, dumpNetipPrefix)
+	RegisterCustomDumper(netip.AddrPort{}, dumpNetipAddrPort)
+}
+
+func dumpNetipAddr(s State, v reflect.Value) {
+	addr := v.Interface().(netip.Addr)
+	dumpAsString(s, v, addr.String())
+}
+
+func dumpNetipPrefix(s State, v reflect.Value) {
+	prefix := v.Interface().(netip.Prefix)
+	dumpAsString(s, v, prefix.String())
+}
+
+func dumpNetipAddrPort(s State, v reflect.Value) {
+	addrPort := v.Interface().(netip.AddrPort)
+	dumpAsString(s, v, addrPort.String())
+}
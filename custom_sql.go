@@ -0,0 +1,100 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+func init() {
+	RegisterCustomDumper(sql.NullBool{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullByte{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullFloat64{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullInt16{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullInt32{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullInt64{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullString{}, dumpSQLNull)
+	RegisterCustomDumper(sql.NullTime{}, dumpSQLNull)
+	// also catches generic sql.Null[T] types
+	RegisterCustomInterfaceDumper((*driver.Valuer)(nil), dumpDriverValuer)
+}
+
+// isSQLNull checks whether the type is one of the sql.Null* types
+func isSQLNull(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t.PkgPath() != "database/sql" || !strings.HasPrefix(t.Name(), "Null") {
+		return false
+	}
+
+	f, ok := t.FieldByName("Valid")
+
+	return ok && f.Type.Kind() == reflect.Bool && t.NumField() == 2
+}
+
+func dumpSQLNull(s State, v reflect.Value) {
+	if !v.FieldByName("Valid").Bool() {
+		s.AddComment(v.Type().String())
+		_, _ = s.Write([]byte("NULL"))
+		return
+	}
+
+	dumpInlineOrField(s, v.Type().Field(0).Name, v.Field(0), v.Type().String())
+}
+
+func dumpDriverValuer(s State, v reflect.Value) {
+	if isSQLNull(v.Type()) {
+		dumpSQLNull(s, v)
+		return
+	}
+
+	value, err := v.Interface().(driver.Valuer).Value()
+	if err != nil {
+		s.AddComment(fmt.Sprintf("%s, Value() failed: %s", v.Type(), err))
+		_, _ = s.Write([]byte("<invalid>"))
+		return
+	}
+
+	comment := fmt.Sprintf("%s as driver.Value", v.Type())
+	if value == nil {
+		s.AddComment(comment)
+		_, _ = s.Write([]byte("NULL"))
+		return
+	}
+
+	dumpInlineOrField(s, "Value", reflect.ValueOf(value), comment)
+}
+
+// dumpInlineOrField dumps scalar values inline with the given comment, and
+// other ones as a field
+func dumpInlineOrField(s State, name string, v reflect.Value, comment string) {
+	switch v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		s.AddComment(comment)
+		s.Dump(v.Interface())
+	default:
+		s.DumpStructField(name, v)
+	}
+}
@@ -22,15 +22,73 @@ package dumper
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"time"
 )
 
 func init() {
 	RegisterCustomDumper(time.Time{}, dumpTime)
+	RegisterCustomDumper(time.Duration(0), dumpDuration)
+	RegisterCustomDumper((*time.Location)(nil), dumpLocation)
 }
 
 func dumpTime(s State, v reflect.Value) {
 	t := v.Interface().(time.Time)
 	s.AddComment(fmt.Sprintf("@%v", t.Unix()))
-	s.DumpStructField("date", reflect.ValueOf(t.Format("2006-01-02 15:04:05.999999 MST (Z07:00)")))
+	if !t.IsZero() {
+		s.AddComment(relativeTime(optionsOf(s).now(), t))
+	}
+	s.DumpStructField("date", reflect.ValueOf(t.Format("2006-01-02 15:04:05.999999999 MST (Z07:00)")))
+
+	// The monotonic clock reading is only exposed by String()
+	if str := t.String(); strings.Contains(str, " m=") {
+		s.DumpStructField("monotonic", reflect.ValueOf(str[strings.LastIndex(str, " m=")+3:]))
+	}
+}
+
+func dumpDuration(s State, v reflect.Value) {
+	d := v.Interface().(time.Duration)
+	s.AddComment(fmt.Sprintf("int64 %d", int64(d)))
+	s.DumpScalar(d, v.Type(), false)
+}
+
+func dumpLocation(s State, v reflect.Value) {
+	loc := v.Interface().(*time.Location)
+	name, offset := optionsOf(s).now().In(loc).Zone()
+	s.AddComment(fmt.Sprintf("%s %s", name, formatOffset(offset)))
+	s.DumpString(loc.String())
+}
+
+func formatOffset(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+
+	return fmt.Sprintf("%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// relativeTime describes t relatively to the reference time, like "3m ago"
+func relativeTime(ref, t time.Time) string {
+	d := ref.Sub(t)
+	format := "%s ago"
+	if d < 0 {
+		d, format = -d, "in %s"
+	}
+
+	var str string
+	switch {
+	case d < time.Second:
+		return "now"
+	case d < time.Minute:
+		str = fmt.Sprintf("%ds", d/time.Second)
+	case d < time.Hour:
+		str = fmt.Sprintf("%dm", d/time.Minute)
+	case d < 24*time.Hour:
+		str = fmt.Sprintf("%dh", d/time.Hour)
+	default:
+		str = fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+
+	return fmt.Sprintf(format, str)
 }
@@ -0,0 +1,72 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"crypto/tls"
+	"fmt"
+	"reflect"
+)
+
+func init() {
+	RegisterCustomDumper(tls.ConnectionState{}, dumpTlsConnectionState)
+}
+
+var tlsVersions = map[uint16]string{
+	tls.VersionTLS10: "TLS 1.0",
+	tls.VersionTLS11: "TLS 1.1",
+	tls.VersionTLS12: "TLS 1.2",
+	tls.VersionTLS13: "TLS 1.3",
+}
+
+func tlsVersionName(version uint16) string {
+	if name, ok := tlsVersions[version]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("0x%04X", version)
+}
+
+func dumpTlsConnectionState(s State, v reflect.Value) {
+	state := v.Interface().(tls.ConnectionState)
+
+	s.AddComment(fmt.Sprintf("0x%04x", state.Version))
+	s.DumpStructField("Version", reflect.ValueOf(tlsVersionName(state.Version)))
+	s.AddComment(fmt.Sprintf("0x%04x", state.CipherSuite))
+	s.DumpStructField("CipherSuite", reflect.ValueOf(tls.CipherSuiteName(state.CipherSuite)))
+	s.DumpStructField("HandshakeComplete", reflect.ValueOf(state.HandshakeComplete))
+	if state.DidResume {
+		s.DumpStructField("DidResume", reflect.ValueOf(true))
+	}
+	if state.ServerName != "" {
+		s.DumpStructField("ServerName", reflect.ValueOf(state.ServerName))
+	}
+	if state.NegotiatedProtocol != "" {
+		s.DumpStructField("NegotiatedProtocol", reflect.ValueOf(state.NegotiatedProtocol))
+	}
+
+	subjects := make([]string, 0, len(state.PeerCertificates))
+	for _, cert := range state.PeerCertificates {
+		subjects = append(subjects, cert.Subject.String())
+	}
+	previous := s.ForceNewLines(true)
+	s.DumpStructField("PeerCertificates", reflect.ValueOf(subjects))
+	s.ForceNewLines(previous)
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+)
+
+func init() {
+	RegisterCustomDumper(url.URL{}, dumpUrl)
+	RegisterCustomDumper(url.Values{}, dumpUrlValues)
+}
+
+func dumpUrl(s State, v reflect.Value) {
+	u := v.Interface().(url.URL)
+
+	s.DumpStructField("URL", reflect.ValueOf(u.String()))
+
+	if u.RawQuery == "" {
+		return
+	}
+
+	query, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		s.AddComment(fmt.Sprintf("invalid query: %s", err))
+	}
+	s.DumpStructField("Query", reflect.ValueOf(query))
+}
+
+func dumpUrlValues(s State, v reflect.Value) {
+	values := v.Interface().(url.Values)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s.AddComment(v.Type().String())
+	_, _ = s.Write([]byte("{"))
+	i := 0
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if i > 0 {
+				_, _ = s.Write([]byte(", "))
+			}
+			s.DumpString(key)
+			_, _ = s.Write([]byte(": "))
+			s.DumpString(value)
+			i++
+		}
+	}
+	_, _ = s.Write([]byte("}"))
+}
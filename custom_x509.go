@@ -0,0 +1,219 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+func init() {
+	RegisterCustomDumper(x509.Certificate{}, dumpX509Certificate)
+	RegisterCustomDumper(rsa.PublicKey{}, dumpRsaPublicKey)
+	RegisterCustomDumper(ecdsa.PublicKey{}, dumpEcdsaPublicKey)
+	RegisterCustomDumper(ed25519.PublicKey{}, dumpEd25519PublicKey)
+	RegisterCustomDumper(rsa.PrivateKey{}, dumpRsaPrivateKey)
+	RegisterCustomDumper(ecdsa.PrivateKey{}, dumpEcdsaPrivateKey)
+	RegisterCustomDumper(ed25519.PrivateKey{}, dumpEd25519PrivateKey)
+}
+
+var keyUsages = []struct {
+	usage x509.KeyUsage
+	name  string
+}{
+	{x509.KeyUsageDigitalSignature, "DigitalSignature"},
+	{x509.KeyUsageContentCommitment, "ContentCommitment"},
+	{x509.KeyUsageKeyEncipherment, "KeyEncipherment"},
+	{x509.KeyUsageDataEncipherment, "DataEncipherment"},
+	{x509.KeyUsageKeyAgreement, "KeyAgreement"},
+	{x509.KeyUsageCertSign, "CertSign"},
+	{x509.KeyUsageCRLSign, "CRLSign"},
+	{x509.KeyUsageEncipherOnly, "EncipherOnly"},
+	{x509.KeyUsageDecipherOnly, "DecipherOnly"},
+}
+
+var extKeyUsages = map[x509.ExtKeyUsage]string{
+	x509.ExtKeyUsageAny:                            "Any",
+	x509.ExtKeyUsageServerAuth:                     "ServerAuth",
+	x509.ExtKeyUsageClientAuth:                     "ClientAuth",
+	x509.ExtKeyUsageCodeSigning:                    "CodeSigning",
+	x509.ExtKeyUsageEmailProtection:                "EmailProtection",
+	x509.ExtKeyUsageIPSECEndSystem:                 "IPSECEndSystem",
+	x509.ExtKeyUsageIPSECTunnel:                    "IPSECTunnel",
+	x509.ExtKeyUsageIPSECUser:                      "IPSECUser",
+	x509.ExtKeyUsageTimeStamping:                   "TimeStamping",
+	x509.ExtKeyUsageOCSPSigning:                    "OCSPSigning",
+	x509.ExtKeyUsageMicrosoftServerGatedCrypto:     "MicrosoftServerGatedCrypto",
+	x509.ExtKeyUsageNetscapeServerGatedCrypto:      "NetscapeServerGatedCrypto",
+	x509.ExtKeyUsageMicrosoftCommercialCodeSigning: "MicrosoftCommercialCodeSigning",
+	x509.ExtKeyUsageMicrosoftKernelCodeSigning:     "MicrosoftKernelCodeSigning",
+}
+
+func dumpX509Certificate(s State, v reflect.Value) {
+	cert := v.Interface().(x509.Certificate)
+
+	s.DumpStructField("Subject", reflect.ValueOf(cert.Subject.String()))
+	s.DumpStructField("Issuer", reflect.ValueOf(cert.Issuer.String()))
+
+	sans := append([]string{}, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	if len(sans) > 0 {
+		s.DumpStructField("SANs", reflect.ValueOf(sans))
+	}
+
+	s.DumpStructField("NotBefore", reflect.ValueOf(formatCertificateTime(cert.NotBefore)))
+	s.AddComment(certificateExpiry(optionsOf(s).now(), cert.NotBefore, cert.NotAfter))
+	s.DumpStructField("NotAfter", reflect.ValueOf(formatCertificateTime(cert.NotAfter)))
+
+	var usages []string
+	for _, u := range keyUsages {
+		if cert.KeyUsage&u.usage != 0 {
+			usages = append(usages, u.name)
+		}
+	}
+	for _, u := range cert.ExtKeyUsage {
+		name, ok := extKeyUsages[u]
+		if !ok {
+			name = fmt.Sprintf("ExtKeyUsage(%d)", u)
+		}
+		usages = append(usages, name)
+	}
+	if len(usages) > 0 {
+		s.DumpStructField("KeyUsage", reflect.ValueOf(usages))
+	}
+	if cert.IsCA {
+		s.DumpStructField("IsCA", reflect.ValueOf(true))
+	}
+
+	if cert.SerialNumber != nil {
+		s.AddComment(cert.SerialNumber.String())
+		s.DumpStructField("SerialNumber", reflect.ValueOf(fmt.Sprintf("%X", cert.SerialNumber)))
+	}
+	s.DumpStructField("SignatureAlgorithm", reflect.ValueOf(cert.SignatureAlgorithm.String()))
+	if cert.PublicKey != nil {
+		s.DumpStructField("PublicKey", reflect.ValueOf(cert.PublicKey))
+	}
+
+	if len(cert.Raw) > 0 {
+		sha256Sum := sha256.Sum256(cert.Raw)
+		s.DumpStructField("SHA256", reflect.ValueOf(formatFingerprint(sha256Sum[:])))
+		sha1Sum := sha1.Sum(cert.Raw)
+		s.DumpStructField("SHA1", reflect.ValueOf(formatFingerprint(sha1Sum[:])))
+	}
+}
+
+func formatCertificateTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05 MST")
+}
+
+// certificateExpiry describes the validity of a certificate at the
+// reference time, like "expires in 30d"
+func certificateExpiry(ref, notBefore, notAfter time.Time) string {
+	switch {
+	case ref.Before(notBefore):
+		return "not valid yet, " + relativeTime(ref, notBefore)
+	case ref.After(notAfter):
+		return "expired " + relativeTime(ref, notAfter)
+	default:
+		return "expires " + relativeTime(ref, notAfter)
+	}
+}
+
+// formatFingerprint formats the digest as colon separated hexadecimal bytes
+func formatFingerprint(sum []byte) string {
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = fmt.Sprintf("%02X", b)
+	}
+
+	return strings.Join(parts, ":")
+}
+
+func dumpKey(s State, algorithm string, bits int) {
+	s.DumpStructField("Algorithm", reflect.ValueOf(algorithm))
+	s.DumpStructField("Bits", reflect.ValueOf(bits))
+}
+
+func rsaBits(key rsa.PublicKey) int {
+	if key.N == nil {
+		return 0
+	}
+
+	return key.N.BitLen()
+}
+
+func ecdsaCurve(key ecdsa.PublicKey) (string, int) {
+	if key.Curve == nil {
+		return "", 0
+	}
+	params := key.Curve.Params()
+
+	return params.Name, params.BitSize
+}
+
+func dumpRsaPublicKey(s State, v reflect.Value) {
+	key := v.Interface().(rsa.PublicKey)
+	dumpKey(s, "RSA", rsaBits(key))
+	s.DumpStructField("Exponent", reflect.ValueOf(key.E))
+}
+
+func dumpEcdsaPublicKey(s State, v reflect.Value) {
+	curve, bits := ecdsaCurve(v.Interface().(ecdsa.PublicKey))
+	dumpKey(s, "ECDSA", bits)
+	s.DumpStructField("Curve", reflect.ValueOf(curve))
+}
+
+func dumpEd25519PublicKey(s State, v reflect.Value) {
+	dumpKey(s, "Ed25519", 256)
+	s.DumpStructField("Key", reflect.ValueOf(fmt.Sprintf("%x", v.Bytes())))
+}
+
+// Private keys never reveal their secret parts, whatever the options
+
+func dumpRsaPrivateKey(s State, v reflect.Value) {
+	dumpKey(s, "RSA", rsaBits(v.Interface().(rsa.PrivateKey).PublicKey))
+	s.DumpStructField("Key", reflect.ValueOf(redactedValue))
+}
+
+func dumpEcdsaPrivateKey(s State, v reflect.Value) {
+	curve, bits := ecdsaCurve(v.Interface().(ecdsa.PrivateKey).PublicKey)
+	dumpKey(s, "ECDSA", bits)
+	s.DumpStructField("Curve", reflect.ValueOf(curve))
+	s.DumpStructField("Key", reflect.ValueOf(redactedValue))
+}
+
+func dumpEd25519PrivateKey(s State, v reflect.Value) {
+	dumpKey(s, "Ed25519", 256)
+	s.DumpStructField("Key", reflect.ValueOf(redactedValue))
+}
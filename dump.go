@@ -24,14 +24,15 @@ import (
 	"fmt"
 	"io"
 	"reflect"
-	"sort"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
 )
 
 type state struct {
-	w io.Writer
+	w    io.Writer
+	opts Options
 
 	styles   map[string]string
 	comments []string
@@ -39,11 +40,19 @@ type state struct {
 	depth                      int
 	forceDumpTypeInstantiation bool
 	forceNewLines              bool
+	noComments                 bool
 
 	pointers           visitedPointersMap
 	currentPointerName string
 
 	lastCaller string
+
+	// errors being dumped, to stop on Unwrap() cycles
+	errorChain []error
+
+	// path of the value being dumped, only tracked when filtering
+	path          string
+	excludedPaths []*regexp.Regexp
 }
 
 func (s *state) Write(p []byte) (int, error) {
@@ -74,14 +83,42 @@ func (s *state) ResetComments() (ret []string) {
 }
 
 func (s *state) formatComments() string {
+	if s.noComments {
+		return ""
+	}
+
 	return fmt.Sprintf(" // %s", strings.Join(s.comments, ", "))
 }
 
+// writeComments writes comments for custom dumpers writing their own lines
+func writeComments(s State, comments ...string) {
+	if ss, ok := s.(*state); ok && ss.noComments {
+		return
+	}
+
+	_, _ = s.Write([]byte(" // " + strings.Join(comments, ", ")))
+}
+
 func (s *state) Dump(value interface{}) {
 	v := reflect.ValueOf(value)
 	s.dumpVal(v)
 }
 
+// pointerComment returns the comment describing the pointer address (and
+// its reference name when the pointer is used several times).
+func (s *state) pointerComment(value reflect.Value) string {
+	return s.WithTempBuffer(func(buf *bytes.Buffer) {
+		if s.currentPointerName != "" {
+			s.printfStyle("ref", "%v ", s.currentPointerName)
+			s.currentPointerName = ""
+		}
+
+		s.printf("(")
+		s.printfStyle("ref", "0x%08x", value.Pointer())
+		s.printf(")")
+	})
+}
+
 func (s *state) DepthUp() {
 	s.depth--
 }
@@ -103,20 +140,19 @@ func (s *state) dumpVal(value reflect.Value) {
 		return
 	}
 
-	typ := value.Type()
-
 	// Handle custom dumpers
-	if typ.Implements(dumpableType) {
-		s.dumpCustom(value, value.Interface().(Dumpable))
+	if dumper := s.customDumperFor(value); dumper != nil {
+		s.dumpCustom(value, dumper)
 		return
 	}
 
-	for t, dumper := range customDumpers {
-		if t == typ {
-			s.dumpCustomFn(value, dumper)
-			return
-		}
-	}
+	s.dumpDefault(value)
+}
+
+// dumpDefault dumps the value using reflection only, bypassing custom dumpers.
+func (s *state) dumpDefault(value reflect.Value) {
+	kind := value.Kind()
+	typ := value.Type()
 
 	switch kind {
 
@@ -156,16 +192,7 @@ func (s *state) dumpVal(value reflect.Value) {
 		} else {
 			previousComments := s.ResetComments()
 
-			s.AddComment(s.WithTempBuffer(func(buf *bytes.Buffer) {
-				if s.currentPointerName != "" {
-					s.printfStyle("ref", "%v ", s.currentPointerName)
-					s.currentPointerName = ""
-				}
-
-				s.printf("(")
-				s.printfStyle("ref", "0x%08x", value.Pointer())
-				s.printf(")")
-			}))
+			s.AddComment(s.pointerComment(value))
 
 			s.printf("&")
 			s.dumpVal(value.Elem())
@@ -176,6 +203,10 @@ func (s *state) dumpVal(value reflect.Value) {
 
 	case reflect.Struct:
 		s.DumpStructType(typ)
+		if s.opts.isTooDeep(s.depth) {
+			s.elide()
+			return
+		}
 		s.printf("{%s\n", s.DumpStructComments(value))
 		s.DepthDown()
 		s.DumpStructFields(value, nil)
@@ -184,6 +215,11 @@ func (s *state) dumpVal(value reflect.Value) {
 		s.printf("}")
 
 	case reflect.Array, reflect.Slice:
+		if typ.Elem() == byteType && !(kind == reflect.Slice && value.IsNil()) {
+			s.dumpBytes(value)
+			return
+		}
+
 		n := value.Len()
 
 		var w io.Writer
@@ -202,26 +238,46 @@ func (s *state) dumpVal(value reflect.Value) {
 			s.AddComment(buf.String())
 
 			s.printfStyle("ref", "nil")
+		} else if s.opts.isTooDeep(s.depth) {
+			s.print(buf.String())
+			s.elide()
 		} else {
 			s.printf("%s{", buf.String())
 
-			if len(s.comments) > 0 && n/ElementsPerLine > 1 || s.forceNewLines {
-				s.print(s.formatComments())
-				s.ResetComments()
-			}
+			if s.forceNewLines {
+				// each element is on its own line, so the slice comments
+				// can only be displayed on the opening line
+				if kind == reflect.Slice {
+					s.AddComment(fmt.Sprintf("len=%d", n))
+				}
 
-			if kind == reflect.Slice {
-				s.AddComment(fmt.Sprintf("len=%d", n))
+				if len(s.comments) > 0 {
+					s.print(s.formatComments())
+					s.ResetComments()
+				}
+			} else {
+				if len(s.comments) > 0 && n/ElementsPerLine > 1 {
+					s.print(s.formatComments())
+					s.ResetComments()
+				}
+
+				if kind == reflect.Slice {
+					s.AddComment(fmt.Sprintf("len=%d", n))
+				}
 			}
 
 			s.DepthDown()
-			for i := 0; i < n; i++ {
+			shown := s.opts.maxItems(n)
+			for i := 0; i < shown; i++ {
 				if s.breakLineIfNecessary(n, i) {
 					s.printf(" ")
 				}
-				s.dumpVal(value.Index(i))
-				s.printf(",")
+				s.scopeComments(func() {
+					s.dumpValAt(s.indexStep(i), value.Index(i))
+					s.printf(",")
+				})
 			}
+			s.dumpHiddenItems(n, shown)
 			s.DepthUp()
 
 			s.breakLineIfNecessary(n, 0)
@@ -245,27 +301,34 @@ func (s *state) dumpVal(value reflect.Value) {
 			s.AddComment(str)
 
 			s.printfStyle("ref", "nil")
+		} else if s.opts.isTooDeep(s.depth) {
+			s.print(str)
+			s.elide()
 		} else {
 			s.printf("%s{", str)
 
-			keys := value.MapKeys()
-			sort.Sort(mapKeysSorter{
-				keys: keys,
-			})
+			keys, values := sortedMapEntries(value)
 			n := len(keys)
+			shown := s.opts.maxItems(n)
 
 			s.DepthDown()
-			for i, k := range keys {
+			for i, k := range keys[:shown] {
 				if s.breakLineIfNecessary(n, i) {
 					s.printf(" ")
 				}
 
-				s.dumpVal(k)
-				s.printf(": ")
-				s.dumpVal(value.MapIndex(k))
-
-				s.printf(",")
+				s.scopeComments(func() {
+					s.dumpVal(k)
+					s.printf(": ")
+					if s.isRedactedKey(k) {
+						s.DumpString(redactedValue)
+					} else {
+						s.dumpValAt(s.keyStep(k), values[i])
+					}
+					s.printf(",")
+				})
 			}
+			s.dumpHiddenItems(n, shown)
 			s.DepthUp()
 
 			s.breakLineIfNecessary(n, 0)
@@ -344,3 +407,43 @@ func (s *state) dumpVal(value reflect.Value) {
 		}
 	}
 }
+
+// scopeComments keeps the comments added while dumping an element of a
+// container on the line of the element when it has its own line, and drops
+// them otherwise as they would pile up on the line of the container
+func (s *state) scopeComments(dumpElement func()) {
+	previous := s.ResetComments()
+	dumpElement()
+	if len(s.comments) > 0 && s.forceNewLines {
+		s.print(s.formatComments())
+	}
+	s.comments = previous
+}
+
+// elide replaces the content of a container nested too deeply
+func (s *state) elide() {
+	s.printfStyle("ref", "{...}")
+}
+
+// dumpHiddenItems marks the end of a container whose elements are not all
+// dumped. An inline marker is used as comments of nested containers would
+// pile up on the same line.
+func (s *state) dumpHiddenItems(n, shown int) {
+	if shown == n {
+		return
+	}
+
+	if s.breakLineIfNecessary(n, shown) {
+		s.printf(" ")
+	}
+	s.printfStyle("ref", "/* %d more */", n-shown)
+}
+
+// isRedactedKey checks whether the value of a map entry must be hidden
+func (s *state) isRedactedKey(k reflect.Value) bool {
+	if k.Kind() == reflect.Interface && !k.IsNil() {
+		k = k.Elem()
+	}
+
+	return k.Kind() == reflect.String && s.opts.IsRedacted(k.String())
+}
@@ -0,0 +1,95 @@
+//go:build go1.21
+
+package dumper
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type Account struct {
+	Name  string
+	Roles []string
+}
+
+// countedDump counts how many times it is dumped
+type countedDump struct {
+	count *int
+}
+
+func (d countedDump) Dump(s State) {
+	*d.count++
+	_, _ = s.Write([]byte("counted"))
+}
+
+func (ts *DumperSuite) TestSlogTextHandler(c *C) {
+	ref := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	buf := &bytes.Buffer{}
+	h := NewSlogTextHandler(buf, nil, Options{})
+
+	r := slog.NewRecord(ref, slog.LevelInfo, "user logged in", 0)
+	r.AddAttrs(
+		slog.Any("account", &Account{Name: "bob", Roles: []string{"admin"}}),
+		slog.Int("attempts", 2),
+		slog.String("agent", "curl/8.0 (linux)"),
+		slog.Any("err", errors.New("not found")),
+		Attr("scopes", map[string]bool{"read": true}),
+		slog.Group("req", slog.String("method", "GET")),
+	)
+	c.Assert(h.WithGroup("auth").WithAttrs([]slog.Attr{slog.Int("id", 7)}).Handle(context.Background(), r), IsNil)
+	c.Assert(buf.String(), Equals, `2030-01-02 03:04:05.000 INFO user logged in auth.id=7 auth.account=&dumper.Account{Name: "bob", Roles: []string{"admin",},} auth.attempts=2 auth.agent="curl/8.0 (linux)" auth.err="not found" auth.scopes=map[string]bool{"read": true,} auth.req.method=GET
+`)
+
+	buf.Reset()
+	h = NewSlogTextHandler(buf, slog.LevelWarn, Options{Multiline: true})
+	r = slog.NewRecord(time.Time{}, slog.LevelWarn, "slow", 0)
+	r.AddAttrs(slog.Any("account", Account{Name: "bob"}), slog.Bool("cached", false))
+	c.Assert(h.Handle(context.Background(), r), IsNil)
+	c.Assert(buf.String(), Equals, `WARN slow cached=false
+  account=dumper.Account{
+    Name: "bob",
+    Roles: nil, // []string
+  }
+`)
+
+	count := 0
+	logger := slog.New(h)
+	logger.Info("discarded", Attr("value", countedDump{&count}))
+	c.Assert(count, Equals, 0)
+	logger.Error("kept", Attr("value", countedDump{&count}))
+	c.Assert(count, Equals, 1)
+}
+
+func (ts *DumperSuite) TestSlogHandler(c *C) {
+	buf := &bytes.Buffer{}
+	h := NewSlogHandler(slog.NewJSONHandler(buf, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey && len(groups) == 0 {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}), Options{})
+
+	slog.New(h).With("account", Account{Name: "bob"}).WithGroup("req").Info("done",
+		"ids", []int{1, 2},
+		Attr("headers", map[string]string{"Accept": "*/*"}),
+		"status", 200,
+		slog.Group("client", "addr", "127.0.0.1"),
+	)
+	c.Assert(buf.String(), Equals, `{"level":"INFO","msg":"done","account":"dumper.Account{Name: \"bob\", Roles: nil,}","req":{"ids":"[]int{1, 2,}","headers":"map[string]string{\"Accept\": \"*/*\",}","status":200,"client":{"addr":"127.0.0.1"}}}
+`)
+
+	buf.Reset()
+	slog.New(h).Info("multiline", "lines", []string{"x\n  y"})
+	c.Assert(buf.String(), Equals, `{"level":"INFO","msg":"multiline","lines":"[]string{\"x\\n  y\",}"}
+`)
+
+	// without a SlogHandler, values are dumped with the default options
+	c.Assert(Attr("ids", []int{1}).Value.Resolve().String(), Equals, `[]int{1,}`)
+}
@@ -0,0 +1,28 @@
+//go:build go1.22
+
+package dumper
+
+import (
+	"database/sql"
+
+	. "gopkg.in/check.v1"
+)
+
+func (ts *DumperSuite) TestSQLNullGeneric(c *C) {
+	type Row struct {
+		Name  sql.Null[string]
+		Count *sql.Null[int]
+		Tags  sql.Null[[]string]
+	}
+
+	c.Assert(Sdump(Row{
+		Count: &sql.Null[int]{V: 3, Valid: true},
+		Tags:  sql.Null[[]string]{V: []string{"a"}, Valid: true},
+	}), DumpEquals, `dumper.Row{
+  Name: NULL, // sql.Null[string]
+  Count: &3, // (0xXXXXXXXXXX), sql.Null[int]
+  Tags: sql.Null[[]string]{
+    V: []string{"a",}, // len=1
+  },
+}`)
+}
@@ -0,0 +1,29 @@
+//go:build go1.18
+
+package dumper
+
+import (
+	"net/netip"
+
+	. "gopkg.in/check.v1"
+)
+
+func (ts *DumperSuite) TestNetip(c *C) {
+	type Route struct {
+		Addr     netip.Addr
+		Prefix   netip.Prefix
+		AddrPort netip.AddrPort
+		Invalid  netip.Addr
+	}
+
+	c.Assert(Sdump(Route{
+		Addr:     netip.MustParseAddr("10.0.0.1"),
+		Prefix:   netip.MustParsePrefix("10.0.0.0/8"),
+		AddrPort: netip.MustParseAddrPort("[::1]:53"),
+	}), DumpEquals, `dumper.Route{
+  Addr: "10.0.0.1", // netip.Addr
+  Prefix: "10.0.0.0/8", // netip.Prefix
+  AddrPort: "[::1]:53", // netip.AddrPort
+  Invalid: "invalid IP", // netip.Addr
+}`)
+}
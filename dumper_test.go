@@ -21,15 +21,46 @@ package dumper
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
 	"fmt"
 	"image"
+	"io"
+	"log"
+	"math"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"net/url"
+	"os"
 	"reflect"
 	"regexp"
+	"runtime"
 	"strings"
+	"sync"
 	"testing"
+	"time"
 	"unsafe"
 
+	"github.com/andybalholm/brotli"
+	pkgerrors "github.com/pkg/errors"
 	. "gopkg.in/check.v1"
 )
 
@@ -193,7 +224,15 @@ func (ts *DumperSuite) TestArray(c *C) {
 	c.Check(Sdump(foo), DumpEquals, `nil // &[2]uint8`)
 
 	foo = &[2]byte{}
-	c.Check(Sdump(foo), DumpEquals, `&[2]uint8{0, 0,} // (0xXXXXXXXXXX)`)
+	c.Check(Sdump(foo), DumpEquals, `&[2]byte{ // (0xXXXXXXXXXX)
+  00000000  00 00                                             |..|
+}`)
+
+	var bar *[2]uint16
+	c.Check(Sdump(bar), DumpEquals, `nil // &[2]uint16`)
+
+	bar = &[2]uint16{}
+	c.Check(Sdump(bar), DumpEquals, `&[2]uint16{0, 0,} // (0xXXXXXXXXXX)`)
 }
 
 func (ts *DumperSuite) TestLongArray(c *C) {
@@ -201,7 +240,20 @@ func (ts *DumperSuite) TestLongArray(c *C) {
 	c.Assert(Sdump(foo), DumpEquals, `nil // &[90]uint8`)
 
 	foo = &[90]byte{}
-	c.Assert(Sdump(foo), DumpEquals, `&[90]uint8{ // (0xXXXXXXXXXX)
+	c.Assert(Sdump(foo), DumpEquals, `&[90]byte{ // (0xXXXXXXXXXX)
+  00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+  00000010  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+  00000020  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+  00000030  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+  00000040  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+  00000050  00 00 00 00 00 00 00 00  00 00                    |..........|
+}`)
+
+	var bar *[90]uint16
+	c.Assert(Sdump(bar), DumpEquals, `nil // &[90]uint16`)
+
+	bar = &[90]uint16{}
+	c.Assert(Sdump(bar), DumpEquals, `&[90]uint16{ // (0xXXXXXXXXXX)
   0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
   0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
   0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
@@ -209,11 +261,53 @@ func (ts *DumperSuite) TestLongArray(c *C) {
 }
 
 func (ts *DumperSuite) TestSlice(c *C) {
+	var foo []int
+	c.Check(Sdump(foo), DumpEquals, `nil // []int`)
+
+	foo = []int{97, 98}
+	c.Check(Sdump(foo), DumpEquals, `[]int{97, 98,} // len=2`)
+}
+
+func (ts *DumperSuite) TestBytes(c *C) {
 	var foo []byte
 	c.Check(Sdump(foo), DumpEquals, `nil // []uint8`)
 
 	foo = []byte{'a', 'b'}
-	c.Check(Sdump(foo), DumpEquals, `[]uint8{97, 98,} // len=2`)
+	c.Check(Sdump(foo), DumpEquals, `"ab" // []byte`)
+
+	foo = []byte("Hello World!\x00\x01\x02\xff")
+	c.Check(Sdump(foo), DumpEquals, `[]byte{ // len=16
+  00000000  48 65 6c 6c 6f 20 57 6f  72 6c 64 21 00 01 02 ff  |Hello World!....|
+}`)
+
+	// json.RawMessage is an alias of jsontext.Value in recent Go versions
+	raw := reflect.TypeOf(json.RawMessage{}).String()
+	c.Check(Sdump(json.RawMessage(`{"id":1}`)), DumpEquals, fmt.Sprintf(`"{"id":1}" // %s`, raw))
+	c.Check(Sdump(json.RawMessage{0x1f, 0x8b, 0x08}), DumpEquals, fmt.Sprintf(`%s{ // len=3
+  00000000  1f 8b 08                                          |...|
+}`, raw))
+
+	type Payload []byte
+	type Message struct {
+		Payload Payload
+		Raw     []byte
+	}
+	c.Check(Sdump(Message{
+		Payload: Payload(`{"foo": "bar"}`),
+		Raw:     make([]byte, 20),
+	}), DumpEquals, `dumper.Message{
+  Payload: "{"foo": "bar"}", // dumper.Payload
+  Raw: []byte{ // len=20
+    00000000  00 00 00 00 00 00 00 00  00 00 00 00 00 00 00 00  |................|
+    00000010  00 00 00 00                                       |....|
+  },
+}`)
+
+	opts := Options{MaxBytes: 4}
+	c.Check(opts.Sdump([]byte("héllo")), DumpEquals, `"hél" // []byte, len=6, truncated to 4 bytes`)
+	c.Check(opts.Sdump([]byte{0, 1, 2, 3, 4, 5}), DumpEquals, `[]byte{ // len=6, truncated to 4 bytes
+  00000000  00 01 02 03                                       |....|
+}`)
 }
 
 func (ts *DumperSuite) TestMap(c *C) {
@@ -227,6 +321,44 @@ func (ts *DumperSuite) TestMap(c *C) {
 	c.Assert(Sdump(foo), DumpEquals, `map[string]bool{"foo": true,}`)
 }
 
+func (ts *DumperSuite) TestMapKeysOrder(c *C) {
+	ints := map[int]string{}
+	for i := 12; i >= -2; i-- {
+		ints[i] = ""
+	}
+	c.Assert(Sdump(ints), DumpEquals, `map[int]string{-2: "", -1: "", 0: "", 1: "", 2: "", 3: "", 4: "", 5: "", 6: "", 7: "", 8: "", 9: "", 10: "", 11: "", 12: "",}`)
+
+	c.Assert(Sdump(map[float64]bool{2.5: true, math.NaN(): true, -1: false, 10: true}), DumpEquals, `map[float64]bool{NaN: true, -1: false, 2.5: true, 10: true,}`)
+
+	c.Assert(Sdump(map[bool]int{true: 1, false: 0}), DumpEquals, `map[bool]int{false: 0, true: 1,}`)
+
+	type Key struct {
+		Name string
+		ID   int
+	}
+	c.Assert(Sdump(map[Key]int{{"b", 1}: 3, {"a", 2}: 2, {"a", 1}: 1}), DumpEquals, `map[Key]int{dumper.Key{
+    Name: "a",
+    ID: 1,
+  }: 1, dumper.Key{
+    Name: "a",
+    ID: 2,
+  }: 2, dumper.Key{
+    Name: "b",
+    ID: 1,
+  }: 3,}`)
+
+	c.Assert(Sdump(map[interface{}]int{"b": 4, 2: 2, nil: 0, "a": 3, 1: 1}), DumpEquals, `map[]int{nil: 0, 1: 1, 2: 2, "a": 3, "b": 4,}`)
+
+	one, two := 1, 2
+	keys := map[*int]int{&two: 2, &one: 1}
+	s := Sdump(keys)
+	if uintptr(unsafe.Pointer(&one)) < uintptr(unsafe.Pointer(&two)) {
+		c.Assert(strings.Index(s, ": 1,") < strings.Index(s, ": 2,"), Equals, true)
+	} else {
+		c.Assert(strings.Index(s, ": 2,") < strings.Index(s, ": 1,"), Equals, true)
+	}
+}
+
 func (ts *DumperSuite) TestInterfaces(c *C) {
 	type Foo struct {
 		Bar interface{}
@@ -350,6 +482,19 @@ Hello World!
   },
   Body: "Hello World!
 ",
+}`)
+
+	resp, err = http.ReadResponse(bufio.NewReader(strings.NewReader("HTTP/1.1 200 OK\r\nTransfer-Encoding: chunked\r\n\r\n5\r\nHello\r\n")), nil)
+	c.Assert(err, IsNil)
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // p0 (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: []string{"chunked",}, // len=1
+  ContentLength: -1, // int64
+  Headers: {
+  },
+  Body: "<STREAMED>",
 }`)
 }
 
@@ -399,8 +544,1478 @@ func (ts *DumperSuite) TestPrivateFields(c *C) {
 }`)
 
 	UnregisterCustomDumper(http.Request{})
+	defer RegisterCustomDumper(http.Request{}, dumpHttpRequest)
 	c.Assert(Sdump(http.Request{}), DumpEquals, httpRequestExceptedDump)
 
 	RegisterCustomDumper(http.Request{}, DumpStructWithPrivateFields)
 	c.Assert(Sdump(http.Request{}), DumpEquals, httpRequestExceptedDumpWithPrivateFields)
 }
+
+type TestPanickingDumper struct {
+	Name string
+}
+
+func (t TestPanickingDumper) Dump(s State) {
+	s.DumpStructField("Name", reflect.ValueOf(t.Name))
+	panic("boom")
+}
+
+type testPanickingDumpFunc struct {
+	ID int
+}
+
+func (ts *DumperSuite) TestCustomDumperPanic(c *C) {
+	c.Assert(Sdump(TestPanickingDumper{Name: "foo"}), DumpEquals, `dumper.TestPanickingDumper{ // custom dumper panicked: boom
+  Name: "foo",
+}`)
+
+	RegisterCustomDumper(testPanickingDumpFunc{}, func(s State, v reflect.Value) {
+		s.ForceNewLines(true)
+		s.DepthDown()
+		_ = v.Interface().(http.Request)
+	})
+	defer UnregisterCustomDumper(testPanickingDumpFunc{})
+
+	c.Assert(Sdump([]testPanickingDumpFunc{{ID: 1}}), DumpEquals, `[]dumper.testPanickingDumpFunc{dumper.testPanickingDumpFunc{ // custom dumper panicked: interface conversion: interface {} is dumper.testPanickingDumpFunc, not http.Request
+    ID: 1,
+  },} // len=1`)
+}
+
+type testMultiError []error
+
+func (e testMultiError) Error() string {
+	return fmt.Sprintf("%d errors", len(e))
+}
+
+func (e testMultiError) Unwrap() []error {
+	return e
+}
+
+type testAPIError struct {
+	Code    int
+	Details []string
+}
+
+func (e *testAPIError) Error() string {
+	return fmt.Sprintf("API error %d", e.Code)
+}
+
+// testLoopError unwraps to its peer, which unwraps back to it
+type testLoopError struct {
+	peer *testLoopError
+}
+
+func (e *testLoopError) Error() string {
+	return "loop"
+}
+
+func (e *testLoopError) Unwrap() error {
+	return e.peer
+}
+
+func (ts *DumperSuite) TestError(c *C) {
+	err := fmt.Errorf("wrapped: %w", errors.New("root"))
+	c.Assert(Sdump(err), DumpEquals, `&fmt.wrapError{ // (0xXXXXXXXXXX)
+  Error: "wrapped: root",
+  Cause: &errors.errorString{ // (0xXXXXXXXXXX)
+    Error: "root",
+  },
+}`)
+
+	type Result struct {
+		Err error
+	}
+	c.Assert(Sdump(Result{Err: testMultiError{errors.New("foo"), err}}), DumpEquals, `dumper.Result{
+  Err: dumper.testMultiError{
+    Error: "2 errors",
+    Causes: {
+      &errors.errorString{ // (0xXXXXXXXXXX)
+        Error: "foo",
+      },
+      &fmt.wrapError{ // (0xXXXXXXXXXX)
+        Error: "wrapped: root",
+        Cause: &errors.errorString{ // (0xXXXXXXXXXX)
+          Error: "root",
+        },
+      },
+    },
+  },
+}`)
+
+	c.Assert(Sdump(Result{}), DumpEquals, `dumper.Result{
+  Err: nil,
+}`)
+
+	c.Assert(Sdump(&os.PathError{Op: "open", Path: "/etc/app.conf", Err: os.ErrNotExist}), DumpEquals, `&fs.PathError{ // (0xXXXXXXXXXX)
+  Error: "open /etc/app.conf: file does not exist",
+  Cause: &errors.errorString{ // (0xXXXXXXXXXX)
+    Error: "file does not exist",
+  },
+  Op: "open",
+  Path: "/etc/app.conf",
+}`)
+
+	c.Assert(Sdump(&testAPIError{Code: 404, Details: []string{"no such user"}}), DumpEquals, `&dumper.testAPIError{ // (0xXXXXXXXXXX)
+  Error: "API error 404",
+  Code: 404,
+  Details: []string{"no such user",}, // len=1
+}`)
+
+	a, b := &testLoopError{}, &testLoopError{}
+	a.peer, b.peer = b, a
+	c.Assert(Sdump(error(a)), DumpEquals, `&dumper.testLoopError{ // (0xXXXXXXXXXX)
+  Error: "loop",
+  Cause: &dumper.testLoopError{ // p0 (0xXXXXXXXXXX)
+    Error: "loop",
+    Cause: <cycle>,
+  },
+}`)
+
+	s := Sdump(pkgerrors.WithStack(errors.New("root")))
+	c.Assert(strings.HasPrefix(s, `&errors.withStack{ // (0x`), Equals, true)
+	c.Assert(strings.Contains(s, "  Stack: {\n    github.com/symfony-cli/dumper.(*DumperSuite).TestError "), Equals, true)
+	c.Assert(strings.HasSuffix(memoryRegexp.ReplaceAllString(s, "0xXXXXXXXXXX"), `  },
+  Cause: &errors.errorString{ // (0xXXXXXXXXXX)
+    Error: "root",
+  },
+}`), Equals, true)
+}
+
+func (ts *DumperSuite) TestNet(c *C) {
+	u, err := url.Parse("https://example.com/search?q=dumper&tag=go&tag=debug#top")
+	c.Assert(err, IsNil)
+	c.Assert(Sdump(u), DumpEquals, `&url.URL{ // (0xXXXXXXXXXX)
+  URL: "https://example.com/search?q=dumper&tag=go&tag=debug#top",
+  Query: {"q": "dumper", "tag": "go", "tag": "debug"}, // url.Values
+}`)
+
+	_, ipNet, err := net.ParseCIDR("192.168.0.0/24")
+	c.Assert(err, IsNil)
+	mac, err := net.ParseMAC("00:00:5e:00:53:01")
+	c.Assert(err, IsNil)
+
+	type Peer struct {
+		IP      net.IP
+		Network *net.IPNet
+		MAC     net.HardwareAddr
+		Addr    *net.TCPAddr
+	}
+	c.Assert(Sdump(Peer{
+		IP:      net.ParseIP("192.168.0.1"),
+		Network: ipNet,
+		MAC:     mac,
+		Addr:    &net.TCPAddr{IP: net.ParseIP("::1"), Port: 8000},
+	}), DumpEquals, `dumper.Peer{
+  IP: "192.168.0.1", // net.IP
+  Network: &"192.168.0.0/24", // (0xXXXXXXXXXX), net.IPNet
+  MAC: "00:00:5e:00:53:01", // net.HardwareAddr
+  Addr: &"[::1]:8000", // (0xXXXXXXXXXX), net.TCPAddr
+}`)
+}
+
+func (ts *DumperSuite) TestTime(c *C) {
+	ref := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	opts := Options{Now: func() time.Time { return ref }}
+
+	type Event struct {
+		At       time.Time
+		Next     time.Time
+		Timeout  time.Duration
+		Location *time.Location
+		Zero     time.Time
+	}
+	c.Assert(opts.Sdump(Event{
+		At:       ref.Add(-3*time.Minute - 123456789*time.Nanosecond),
+		Next:     ref.Add(49 * time.Hour),
+		Timeout:  1500 * time.Millisecond,
+		Location: time.FixedZone("UTC-8", -8*60*60),
+	}), DumpEquals, `dumper.Event{
+  At: time.Time{
+    date: "2021-06-15 11:56:59.876543211 UTC (Z)", // @1623758219, 3m ago
+  },
+  Next: time.Time{
+    date: "2021-06-17 13:00:00 UTC (Z)", // @1623934800, in 2d
+  },
+  Timeout: 1.5s, // int64 1500000000
+  Location: "UTC-8", // UTC-8 -08:00
+  Zero: time.Time{
+    date: "0001-01-01 00:00:00 UTC (Z)", // @-62135596800
+  },
+}`)
+
+	c.Assert(opts.Sdump(2*time.Hour), DumpEquals, `2h0m0s // int64 7200000000000`)
+
+	durations := []time.Duration{time.Second, 2 * time.Second}
+	c.Assert(opts.Sdump(durations), DumpEquals, `[]time.Duration{1s, 2s,} // len=2`)
+	c.Assert(Options{Multiline: true}.Sdump(durations), DumpEquals, `[]time.Duration{ // len=2
+  1s, // int64 1000000000
+  2s, // int64 2000000000
+}`)
+
+	later := Options{Now: func() time.Time { return ref.Add(time.Hour) }}
+	c.Assert(later.Sdump(ref), DumpEquals, `time.Time{
+  date: "2021-06-15 12:00:00 UTC (Z)", // @1623758400, 1h ago
+}`)
+
+	s := opts.Sdump(time.Now())
+	c.Assert(strings.Contains(s, "\n  monotonic: \"+"), Equals, true)
+}
+
+type cacheEntry struct {
+	ttl     time.Duration
+	at      time.Time
+	origin  url.URL
+	size    *big.Int
+	peer    net.IP
+	entries map[string]time.Duration
+}
+
+func (ts *DumperSuite) TestPrivateFieldsWithCustomDumpers(c *C) {
+	ref := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	entry := cacheEntry{
+		ttl:     time.Second,
+		at:      ref,
+		origin:  url.URL{Scheme: "https", Host: "example.com"},
+		size:    big.NewInt(42),
+		peer:    net.ParseIP("10.0.0.1"),
+		entries: map[string]time.Duration{"a": time.Minute},
+	}
+	expected := `dumper.cacheEntry{
+  ttl: 1s, // int64 1000000000
+  at: time.Time{
+    date: "2021-06-15 12:00:00 UTC (Z)", // @1623758400, now
+  },
+  origin: url.URL{
+    URL: "https://example.com",
+  },
+  size: 42, // *big.Int
+  peer: "10.0.0.1", // net.IP
+  entries: map[string]time.Duration{"a": 1m0s,},
+}`
+	opts := Options{Now: func() time.Time { return ref }}
+	c.Assert(opts.Sdump(entry), DumpEquals, expected)
+	c.Assert(opts.Sdump(&entry), DumpEquals, "&"+strings.Replace(expected, "{\n", "{ // (0xXXXXXXXXXX)\n", 1))
+}
+
+type testStatus int
+
+func (s testStatus) Value() (driver.Value, error) {
+	if s == 0 {
+		return nil, nil
+	}
+	if s < 0 {
+		return nil, errors.New("unknown status")
+	}
+
+	return fmt.Sprintf("status-%d", s), nil
+}
+
+func (ts *DumperSuite) TestSQL(c *C) {
+	c.Assert(Sdump(sql.NullString{String: "foo", Valid: true}), DumpEquals, `"foo" // sql.NullString`)
+	c.Assert(Sdump(sql.NullString{String: "foo"}), DumpEquals, `NULL // sql.NullString`)
+
+	ref := time.Date(2021, 6, 15, 12, 0, 0, 0, time.UTC)
+	opts := Options{Now: func() time.Time { return ref }}
+
+	type Row struct {
+		ID        sql.NullInt64
+		Score     *sql.NullFloat64
+		Enabled   sql.NullBool
+		CreatedAt sql.NullTime
+		DeletedAt sql.NullTime
+		Status    testStatus
+		Previous  testStatus
+		Invalid   testStatus
+	}
+	c.Assert(opts.Sdump(Row{
+		ID:        sql.NullInt64{Int64: 42, Valid: true},
+		Score:     &sql.NullFloat64{Float64: 1.5, Valid: true},
+		CreatedAt: sql.NullTime{Time: ref, Valid: true},
+		Status:    2,
+		Invalid:   -1,
+	}), DumpEquals, `dumper.Row{
+  ID: 42, // sql.NullInt64
+  Score: &1.5, // (0xXXXXXXXXXX), sql.NullFloat64
+  Enabled: NULL, // sql.NullBool
+  CreatedAt: sql.NullTime{
+    Time: time.Time{
+      date: "2021-06-15 12:00:00 UTC (Z)", // @1623758400, now
+    },
+  },
+  DeletedAt: NULL, // sql.NullTime
+  Status: "status-2", // dumper.testStatus as driver.Value
+  Previous: NULL, // dumper.testStatus as driver.Value
+  Invalid: <invalid>, // dumper.testStatus, Value() failed: unknown status
+}`)
+}
+
+type testID struct {
+	prefix string
+	n      int
+}
+
+func (id *testID) String() string {
+	return fmt.Sprintf("%s-%04d", id.prefix, id.n)
+}
+
+func (ts *DumperSuite) TestBig(c *C) {
+	i, ok := new(big.Int).SetString("123456789012345678901234567890", 10)
+	c.Assert(ok, Equals, true)
+	c.Assert(Sdump(i), DumpEquals, `123456789012345678901234567890 // *big.Int`)
+
+	type Amounts struct {
+		Total big.Int
+		Rate  *big.Float
+		Share *big.Rat
+		Count *big.Rat
+		Unset *big.Int
+	}
+	c.Assert(Sdump(Amounts{
+		Total: *big.NewInt(-42),
+		Rate:  new(big.Float).SetPrec(100).SetFloat64(0.125),
+		Share: big.NewRat(2, 3),
+		Count: big.NewRat(6, 3),
+	}), DumpEquals, `dumper.Amounts{
+  Total: -42, // big.Int
+  Rate: 0.125, // *big.Float, prec=100, mode=ToNearestEven
+  Share: 2/3, // *big.Rat, = 0.6666666667
+  Count: 2, // *big.Rat
+  Unset: nil, // &big.Int
+}`)
+}
+
+func (ts *DumperSuite) TestDumpStringer(c *C) {
+	RegisterCustomDumper(testID{}, DumpStringer)
+	defer UnregisterCustomDumper(testID{})
+
+	c.Assert(Sdump(testID{prefix: "user", n: 42}), DumpEquals, `"user-0042" // dumper.testID`)
+	c.Assert(Sdump([]testID{{prefix: "a", n: 1}}), DumpEquals, `[]dumper.testID{"a-0001",} // len=1`)
+
+	RegisterCustomDumper(image.Point{}, DumpStringer)
+	defer UnregisterCustomDumper(image.Point{})
+	c.Assert(Sdump(image.Point{X: 1, Y: 2}), DumpEquals, `"(1,2)" // image.Point`)
+}
+
+type testLevel int8
+
+func (l testLevel) String() string {
+	return [...]string{"debug", "info", "error"}[l]
+}
+
+type testToken struct {
+	Value string
+	owner *testUser
+}
+
+func (t testToken) MarshalText() ([]byte, error) {
+	return []byte("token of " + t.owner.Name), nil
+}
+
+type testUser struct {
+	Name  string
+	Level testLevel
+	Token testToken
+}
+
+func (u *testUser) GoString() string {
+	return fmt.Sprintf("testUser(%q)", u.Name)
+}
+
+func (ts *DumperSuite) TestMethodFallbacks(c *C) {
+	u := &testUser{Name: "bob", Level: 1}
+	u.Token = testToken{Value: "secret", owner: u}
+
+	c.Assert(Sdump(testLevel(2)), DumpEquals, `testLevel(2)`)
+
+	opts := Options{Fallbacks: FallbackStringer | FallbackTextMarshaler}
+	c.Assert(opts.Sdump(testLevel(2)), DumpEquals, `"error" // dumper.testLevel.String(), 2`)
+	c.Assert(opts.Sdump(u), DumpEquals, `&dumper.testUser{ // p0 (0xXXXXXXXXXX)
+  Name: "bob",
+  Level: "info", // dumper.testLevel.String(), 1
+  Token: dumper.testToken{
+    MarshalText(): "token of bob",
+    Value: "secret",
+    owner: p0,
+  },
+}`)
+
+	c.Assert(opts.Sdump(testToken{Value: "orphan"}), DumpEquals, `dumper.testToken{
+  MarshalText(): <invalid>, // MarshalText() panicked: runtime error: invalid memory address or nil pointer dereference
+  Value: "orphan",
+  owner: nil, // &dumper.testUser
+}`)
+	c.Assert(opts.Sdump(testLevel(5)), DumpEquals, `5 // dumper.testLevel, String() panicked: runtime error: index out of range [5] with length 3`)
+
+	opts = Options{Fallbacks: FallbackGoStringer}
+	c.Assert(opts.Sdump(testUser{Name: "alice"}), DumpEquals, `dumper.testUser{
+  GoString(): "testUser("alice")",
+  Name: "alice",
+  Level: 0, // testLevel
+  Token: dumper.testToken{
+    Value: "",
+    owner: nil, // &dumper.testUser
+  },
+}`)
+}
+
+func (ts *DumperSuite) TestTransport(c *C) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.Header().Set("Content-Length", fmt.Sprint(len(body)+7))
+		fmt.Fprintf(w, "echoed %s", body)
+	}))
+	defer server.Close()
+
+	out := &strings.Builder{}
+	client := &http.Client{
+		Transport: &Transport{Out: out, Options: Options{Redact: SensitiveHeaders}},
+	}
+	req, err := http.NewRequest("POST", server.URL+"/foo", strings.NewReader("hello"))
+	c.Assert(err, IsNil)
+	req.Header.Set("Content-Type", "text/plain")
+	req.Header.Set("Authorization", "Bearer secret")
+
+	resp, err := client.Do(req)
+	c.Assert(err, IsNil)
+	body, err := io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(resp.Body.Close(), IsNil)
+	c.Assert(string(body), Equals, "echoed hello")
+
+	dump := regexp.MustCompile(`#\d+`).ReplaceAllString(out.String(), "#N")
+	dump = regexp.MustCompile(`\(\d.*s\)\n`).ReplaceAllString(dump, "(XXms)\n")
+	dump = regexp.MustCompile(`"Date": ".*",`).ReplaceAllString(dump, `"Date": "XXX",`)
+	dump = strings.Replace(dump, server.URL, "http://server", -1)
+	c.Assert(dump, DumpEquals, `--> #N POST http://server/foo
+&http.Request{ // (0xXXXXXXXXXX)
+  URL: "http://server/foo",
+  Method: "POST",
+  Proto: "HTTP/1.1",
+  ContentLength: 5, // int64
+  Headers: {
+    "Authorization": "<redacted>",
+    "Content-Type": "text/plain",
+  },
+  Body: "hello",
+}
+<-- #N 200 OK (XXms)
+&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 12, // int64
+  Headers: {
+    "Content-Length": "12",
+    "Content-Type": "text/plain",
+    "Date": "XXX",
+  },
+  Body: "echoed hello",
+}`)
+
+	// responses of unknown length are not read, as they might never end
+	stream, w := io.Pipe()
+	out.Reset()
+	client.Transport = &Transport{Out: out, Base: roundTripperFunc(func(r *http.Request) (*http.Response, error) {
+		return &http.Response{Status: "200 OK", StatusCode: 200, Proto: "HTTP/2.0", ContentLength: -1, Body: stream}, nil
+	})}
+	resp, err = client.Get(server.URL)
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasSuffix(out.String(), `
+  ContentLength: -1, // int64
+  Headers: {
+  },
+  Body: "<STREAMED>",
+}
+`), Equals, true)
+	go func() {
+		fmt.Fprint(w, "event")
+		_ = w.Close()
+	}()
+	body, err = io.ReadAll(resp.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, "event")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(r *http.Request) (*http.Response, error) {
+	return f(r)
+}
+
+func (ts *DumperSuite) TestMiddleware(c *C) {
+	out := &strings.Builder{}
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Request-Body", string(body))
+		w.WriteHeader(http.StatusCreated)
+		fmt.Fprint(w, "Hello World, this is a long response")
+	}), MiddlewareOptions{
+		Out:     out,
+		Options: Options{MaxBytes: 11},
+		Methods: []string{"post"},
+		Paths:   []string{"/api/*"},
+	})
+
+	for _, r := range []*http.Request{
+		httptest.NewRequest("POST", "/api/users", strings.NewReader("name=bob")),
+		httptest.NewRequest("GET", "/api/users", nil),
+		httptest.NewRequest("POST", "/login", nil),
+	} {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		c.Assert(w.Code, Equals, http.StatusCreated)
+		c.Assert(w.Body.String(), Equals, "Hello World, this is a long response")
+	}
+	c.Assert(strings.Count(out.String(), "<-- #"), Equals, 1)
+
+	dump := regexp.MustCompile(`#\d+`).ReplaceAllString(out.String(), "#N")
+	dump = regexp.MustCompile(`\(\d.*s\)\n`).ReplaceAllString(dump, "(XXms)\n")
+	c.Assert(dump, DumpEquals, `<-- #N POST /api/users
+&http.Request{ // (0xXXXXXXXXXX)
+  URL: "/api/users",
+  Method: "POST",
+  Proto: "HTTP/1.1",
+  ContentLength: 8, // int64
+  Headers: {
+    "Content-Type": "application/x-www-form-urlencoded",
+  },
+  Body: {"name": "bob"}, // url.Values
+}
+--> #N 201 Created (XXms)
+&http.Response{ // (0xXXXXXXXXXX)
+  Status: "201 Created",
+  StatusCode: 201,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 36, // int64
+  Headers: {
+    "Content-Type": "text/plain; charset=utf-8",
+    "X-Request-Body": "name=bob",
+  },
+  Body: "Hello World", // truncated to 11 bytes
+}`)
+}
+
+func (ts *DumperSuite) TestMiddlewareHijackAndInformational(c *C) {
+	out := &lockedBuffer{}
+	srv := httptest.NewServer(Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/early-hints" {
+			w.Header().Set("Link", "</app.css>; rel=preload")
+			w.WriteHeader(http.StatusEarlyHints)
+			w.WriteHeader(http.StatusOK)
+			fmt.Fprint(w, "ok")
+			return
+		}
+
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer conn.Close()
+		_, _ = buf.WriteString("HTTP/1.1 200 OK\r\nContent-Length: 8\r\nConnection: close\r\n\r\nhijacked")
+		_ = buf.Flush()
+	}), MiddlewareOptions{Out: out}))
+	defer srv.Close()
+
+	for path, body := range map[string]string{"/early-hints": "ok", "/upgrade": "hijacked"} {
+		resp, err := http.Get(srv.URL + path)
+		c.Assert(err, IsNil)
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		c.Assert(err, IsNil)
+		c.Assert(string(data), Equals, body)
+	}
+
+	dump := out.waitFor(40)
+	c.Assert(strings.Contains(dump, " 200 OK ("), Equals, true)
+	c.Assert(strings.Contains(dump, " 101 Switching Protocols ("), Equals, true)
+	c.Assert(strings.Contains(dump, "103"), Equals, false)
+}
+func (ts *DumperSuite) TestHttpBodyCapture(c *C) {
+	req, err := http.NewRequest("POST", "http://example.com/upload", strings.NewReader("0123456789abcdef"))
+	c.Assert(err, IsNil)
+	req.Header.Set("Content-Type", "text/plain")
+
+	// a copy of the request cannot get its body back, so it is not read
+	c.Assert(Sdump(*req), DumpEquals, `http.Request{
+  URL: "http://example.com/upload",
+  Method: "POST",
+  Proto: "HTTP/1.1",
+  ContentLength: 16, // int64
+  Headers: {
+    "Content-Type": "text/plain",
+  },
+  Body: "<UNREAD>", // dump a pointer to read the body
+}`)
+
+	opts := Options{MaxBytes: 10}
+	c.Assert(opts.Sdump(req), DumpEquals, `&http.Request{ // (0xXXXXXXXXXX)
+  URL: "http://example.com/upload",
+  Method: "POST",
+  Proto: "HTTP/1.1",
+  ContentLength: 16, // int64
+  Headers: {
+    "Content-Type": "text/plain",
+  },
+  Body: "0123456789", // truncated to 10 bytes
+}`)
+
+	body, err := io.ReadAll(req.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, "0123456789abcdef")
+}
+
+func newBodyResponse(contentType, encoding string, body []byte) *http.Response {
+	header := http.Header{"Content-Type": {contentType}}
+	if encoding != "" {
+		header.Set("Content-Encoding", encoding)
+	}
+
+	return &http.Response{
+		Status:        "200 OK",
+		StatusCode:    200,
+		Proto:         "HTTP/1.1",
+		Header:        header,
+		ContentLength: int64(len(body)),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+	}
+}
+
+func (ts *DumperSuite) TestHttpBodyRendering(c *C) {
+	resp := newBodyResponse("application/json; charset=utf-8", "", []byte(`{"name":"bob","tags":["a","b"],"age":42}`))
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 40, // int64
+  Headers: {
+    "Content-Type": "application/json; charset=utf-8",
+  },
+  Body: map[string]interface {}{
+    "age": 42,
+    "name": "bob",
+    "tags": []interface {}{ // len=2
+      "a",
+      "b",
+    },
+  },
+}`)
+
+	resp = newBodyResponse("application/json", "", []byte(`{"id": 12345678901234567890, "n": 1000000, "ratio": 0.10}`))
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 57, // int64
+  Headers: {
+    "Content-Type": "application/json",
+  },
+  Body: map[string]interface {}{
+    "id": 12345678901234567890, // json.Number
+    "n": 1000000,
+    "ratio": 0.10, // json.Number
+  },
+}`)
+
+	resp = newBodyResponse("application/problem+json", "", []byte(`{"title":`))
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 9, // int64
+  Headers: {
+    "Content-Type": "application/problem+json",
+  },
+  Body: "{"title":", // invalid JSON: unexpected EOF
+}`)
+
+	resp = newBodyResponse("application/x-www-form-urlencoded; charset=utf-8", "", []byte("b=2&a=1&a=3"))
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 11, // int64
+  Headers: {
+    "Content-Type": "application/x-www-form-urlencoded; charset=utf-8",
+  },
+  Body: {"a": "1", "a": "3", "b": "2"}, // url.Values
+}`)
+
+	resp = newBodyResponse("application/xml", "", []byte(`<a><b id="1">x</b>  <c/></a>`))
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 28, // int64
+  Headers: {
+    "Content-Type": "application/xml",
+  },
+  Body: "<a>
+  <b id="1">x</b>
+  <c></c>
+</a>",
+}`)
+
+	// namespaces are kept as written
+	indented, err := indentXML([]byte(`<m:Get xmlns:m="urn:x"><m:Id m:type="int">1</m:Id><Plain xmlns="urn:y"/></m:Get>`))
+	c.Assert(err, IsNil)
+	c.Assert(string(indented), Equals, `<m:Get xmlns:m="urn:x">
+  <m:Id m:type="int">1</m:Id>
+  <Plain xmlns="urn:y"></Plain>
+</m:Get>`)
+
+	buf := &bytes.Buffer{}
+	mw := multipart.NewWriter(buf)
+	c.Assert(mw.SetBoundary("BOUNDARY"), IsNil)
+	c.Assert(mw.WriteField("name", "bob"), IsNil)
+	fw, err := mw.CreateFormFile("avatar", "avatar.png")
+	c.Assert(err, IsNil)
+	_, _ = fw.Write([]byte{0x89, 'P', 'N', 'G'})
+	c.Assert(mw.Close(), IsNil)
+	resp = newBodyResponse(mw.FormDataContentType(), "", buf.Bytes())
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 208, // int64
+  Headers: {
+    "Content-Type": "multipart/form-data; boundary=BOUNDARY",
+  },
+  Body: {
+    {
+      Headers: {
+        "Content-Disposition": "form-data; name="name"",
+      },
+      Size: 3,
+      Content: "bob",
+    },
+    {
+      Headers: {
+        "Content-Disposition": "form-data; name="avatar"; filename="avatar.png"",
+        "Content-Type": "application/octet-stream",
+      },
+      Size: 4,
+      Content: "<BINARY>",
+    },
+  },
+}`)
+
+	buf = &bytes.Buffer{}
+	gw := gzip.NewWriter(buf)
+	_, _ = gw.Write([]byte("Hello gzip"))
+	c.Assert(gw.Close(), IsNil)
+	resp = newBodyResponse("text/plain", "gzip", buf.Bytes())
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 35, // int64
+  Headers: {
+    "Content-Encoding": "gzip",
+    "Content-Type": "text/plain",
+  },
+  Body: "Hello gzip", // gzip decoded
+}`)
+
+	buf = &bytes.Buffer{}
+	bw := brotli.NewWriter(buf)
+	_, _ = bw.Write([]byte(`{"compressed":true}`))
+	c.Assert(bw.Close(), IsNil)
+	resp = newBodyResponse("application/json", "br", buf.Bytes())
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 23, // int64
+  Headers: {
+    "Content-Encoding": "br",
+    "Content-Type": "application/json",
+  },
+  Body: map[string]interface {}{
+    "compressed": true,
+  }, // br decoded
+}`)
+
+	resp = newBodyResponse("text/plain", "compress", []byte("xxx"))
+	c.Assert(Sdump(resp), DumpEquals, `&http.Response{ // (0xXXXXXXXXXX)
+  Status: "200 OK",
+  StatusCode: 200,
+  Proto: "HTTP/1.1",
+  TransferEncoding: nil, // []string
+  ContentLength: 3, // int64
+  Headers: {
+    "Content-Encoding": "compress",
+    "Content-Type": "text/plain",
+  },
+  Body: "<BINARY>", // failed to decode the compress body: unsupported encoding
+}`)
+}
+
+func (ts *DumperSuite) TestHttpTypes(c *C) {
+	ref := time.Date(2030, 1, 1, 3, 4, 5, 0, time.UTC)
+	opts := Options{Now: func() time.Time { return ref }}
+
+	header := http.Header{"X-B": {"2"}, "Accept": {"a", "b"}, "Authorization": {"secret"}}
+	c.Assert(opts.Sdump(header), DumpEquals, `http.Header{
+  "Accept": "a",
+  "Accept": "b",
+  "Authorization": "secret",
+  "X-B": "2",
+}`)
+	c.Assert(Options{Redact: SensitiveHeaders}.Sdump(header), DumpEquals, `http.Header{
+  "Accept": "a",
+  "Accept": "b",
+  "Authorization": "<redacted>",
+  "X-B": "2",
+}`)
+
+	cookie := &http.Cookie{
+		Name:     "sid",
+		Value:    "abc",
+		Path:     "/",
+		Domain:   "example.com",
+		Expires:  ref.Add(24 * time.Hour),
+		MaxAge:   3600,
+		Secure:   true,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	c.Assert(opts.Sdump(cookie), DumpEquals, `&http.Cookie{ // (0xXXXXXXXXXX)
+  Name: "sid",
+  Value: "abc",
+  Path: "/",
+  Domain: "example.com",
+  Expires: time.Time{
+    date: "2030-01-02 03:04:05 UTC (Z)", // @1893553445, in 1d
+  },
+  MaxAge: 1h0m0s, // int64 3600000000000
+  Secure: true,
+  HttpOnly: true,
+  SameSite: "Lax",
+}`)
+	c.Assert(Options{Redact: SensitiveHeaders}.Sdump(&http.Cookie{Name: "sid", Value: "abc", MaxAge: -1}), DumpEquals, `&http.Cookie{ // (0xXXXXXXXXXX)
+  Name: "sid",
+  Value: "<redacted>",
+  MaxAge: -1, // deleted
+}`)
+
+	c.Assert(opts.Sdump(http.Client{}), DumpEquals, `http.Client{
+  Transport: "http.DefaultTransport",
+  Timeout: 0s, // no timeout, int64 0
+}`)
+	c.Assert(opts.Sdump(&http.Client{Transport: &Transport{}, Timeout: 5 * time.Second}), DumpEquals, `&http.Client{ // (0xXXXXXXXXXX)
+  Transport: "*dumper.Transport",
+  Timeout: 5s, // int64 5000000000
+}`)
+
+	c.Assert(opts.Sdump(&http.Server{Addr: ":8080", Handler: http.NewServeMux(), ReadTimeout: time.Second}), DumpEquals, `&http.Server{ // (0xXXXXXXXXXX)
+  Addr: ":8080",
+  Handler: "*http.ServeMux",
+  TLS: false,
+  ReadTimeout: 1s, // int64 1000000000
+}`)
+	c.Assert(opts.Sdump(&http.Server{TLSConfig: &tls.Config{}}), DumpEquals, `&http.Server{ // (0xXXXXXXXXXX)
+  Addr: "", // listens on :http or :https
+  Handler: "http.DefaultServeMux",
+  TLS: true,
+}`)
+
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	defer srv.Close()
+	state := &tls.ConnectionState{
+		Version:           tls.VersionTLS13,
+		CipherSuite:       tls.TLS_AES_128_GCM_SHA256,
+		HandshakeComplete: true,
+		ServerName:        "example.com",
+		PeerCertificates:  []*x509.Certificate{srv.Certificate()},
+	}
+	c.Assert(opts.Sdump(state), DumpEquals, `&tls.ConnectionState{ // (0xXXXXXXXXXX)
+  Version: "TLS 1.3", // 0x0304
+  CipherSuite: "TLS_AES_128_GCM_SHA256", // 0x1301
+  HandshakeComplete: true,
+  ServerName: "example.com",
+  PeerCertificates: []string{ // len=1
+    "O=Acme Co",
+  },
+}`)
+
+	form := &multipart.Form{
+		Value: map[string][]string{"name": {"bob"}, "tags": {"a", "b"}},
+		File: map[string][]*multipart.FileHeader{
+			"avatar": {{Filename: "avatar.png", Size: 4, Header: textproto.MIMEHeader{"Content-Type": {"image/png"}}}},
+		},
+	}
+	c.Assert(opts.Sdump(form), DumpEquals, `&multipart.Form{ // (0xXXXXXXXXXX)
+  Value: {
+    "name": "bob",
+    "tags": "a",
+    "tags": "b",
+  },
+  File: {
+    "avatar": "avatar.png", // image/png, 4 bytes
+  },
+}`)
+}
+
+const testCertificate = `-----BEGIN CERTIFICATE-----
+MIIBkTCCATagAwIBAgICEAAwCgYIKoZIzj0EAwIwJTENMAsGA1UEChMEQWNtZTEU
+MBIGA1UEAxMLZXhhbXBsZS5jb20wHhcNMzAwMTAxMDAwMDAwWhcNMzEwMTAxMDAw
+MDAwWjAlMQ0wCwYDVQQKEwRBY21lMRQwEgYDVQQDEwtleGFtcGxlLmNvbTBZMBMG
+ByqGSM49AgEGCCqGSM49AwEHA0IABGdHEcTWUIPlQrvZcrxsIun0ZDSh7uXDjIFP
+LX2NFEbGHnqpr2KoNIASwOM4EYrsq10OAKereAhDu4sCZ3JTwpujVjBUMA4GA1Ud
+DwEB/wQEAwIFoDATBgNVHSUEDDAKBggrBgEFBQcDATAtBgNVHREEJjAkggtleGFt
+cGxlLmNvbYIPd3d3LmV4YW1wbGUuY29thwR/AAABMAoGCCqGSM49BAMCA0kAMEYC
+IQCfd0obmYlNJhPsTPXUSgSg5HC2PNZJSaBGfcUlYUgkhwIhAKw40zvyhIKlMB7m
+nvm6H8I+/FNpCR/+d9BzGn/chCDx
+-----END CERTIFICATE-----`
+
+func (ts *DumperSuite) TestX509(c *C) {
+	ref := time.Date(2030, 12, 2, 0, 0, 0, 0, time.UTC)
+	opts := Options{Now: func() time.Time { return ref }}
+
+	block, _ := pem.Decode([]byte(testCertificate))
+	cert, err := x509.ParseCertificate(block.Bytes)
+	c.Assert(err, IsNil)
+	c.Assert(opts.Sdump(cert), DumpEquals, `&x509.Certificate{ // (0xXXXXXXXXXX)
+  Subject: "CN=example.com,O=Acme",
+  Issuer: "CN=example.com,O=Acme",
+  SANs: []string{"example.com", "www.example.com", "127.0.0.1",}, // len=3
+  NotBefore: "2030-01-01 00:00:00 UTC",
+  NotAfter: "2031-01-01 00:00:00 UTC", // expires in 30d
+  KeyUsage: []string{"DigitalSignature", "KeyEncipherment", "ServerAuth",}, // len=3
+  SerialNumber: "1000", // 4096
+  SignatureAlgorithm: "ECDSA-SHA256",
+  PublicKey: &ecdsa.PublicKey{ // (0xXXXXXXXXXX)
+    Algorithm: "ECDSA",
+    Bits: 256,
+    Curve: "P-256",
+  },
+  SHA256: "75:15:55:5D:78:03:58:B0:28:FE:7E:D4:E3:BD:C6:DF:D9:4E:8F:06:96:F5:EB:96:35:FE:0F:35:DE:CD:3B:F6",
+  SHA1: "4E:96:CC:21:AC:50:B3:F7:CB:52:E3:46:76:2C:5D:D4:90:41:A5:FB",
+}`)
+
+	opts.Now = func() time.Time { return ref.AddDate(1, 0, 0) }
+	c.Assert(opts.Sdump(cert), DumpEquals, `&x509.Certificate{ // (0xXXXXXXXXXX)
+  Subject: "CN=example.com,O=Acme",
+  Issuer: "CN=example.com,O=Acme",
+  SANs: []string{"example.com", "www.example.com", "127.0.0.1",}, // len=3
+  NotBefore: "2030-01-01 00:00:00 UTC",
+  NotAfter: "2031-01-01 00:00:00 UTC", // expired 335d ago
+  KeyUsage: []string{"DigitalSignature", "KeyEncipherment", "ServerAuth",}, // len=3
+  SerialNumber: "1000", // 4096
+  SignatureAlgorithm: "ECDSA-SHA256",
+  PublicKey: &ecdsa.PublicKey{ // (0xXXXXXXXXXX)
+    Algorithm: "ECDSA",
+    Bits: 256,
+    Curve: "P-256",
+  },
+  SHA256: "75:15:55:5D:78:03:58:B0:28:FE:7E:D4:E3:BD:C6:DF:D9:4E:8F:06:96:F5:EB:96:35:FE:0F:35:DE:CD:3B:F6",
+  SHA1: "4E:96:CC:21:AC:50:B3:F7:CB:52:E3:46:76:2C:5D:D4:90:41:A5:FB",
+}`)
+
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 1024)
+	c.Assert(err, IsNil)
+	c.Assert(opts.Sdump(rsaKey), DumpEquals, `&rsa.PrivateKey{ // (0xXXXXXXXXXX)
+  Algorithm: "RSA",
+  Bits: 1024,
+  Key: "<redacted>",
+}`)
+	c.Assert(opts.Sdump(rsaKey.Public()), DumpEquals, `&rsa.PublicKey{ // (0xXXXXXXXXXX)
+  Algorithm: "RSA",
+  Bits: 1024,
+  Exponent: 65537,
+}`)
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	c.Assert(err, IsNil)
+	c.Assert(opts.Sdump(ecdsaKey), DumpEquals, `&ecdsa.PrivateKey{ // (0xXXXXXXXXXX)
+  Algorithm: "ECDSA",
+  Bits: 384,
+  Curve: "P-384",
+  Key: "<redacted>",
+}`)
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	c.Assert(err, IsNil)
+	c.Assert(opts.Sdump(edKey), DumpEquals, `ed25519.PrivateKey{
+  Algorithm: "Ed25519",
+  Bits: 256,
+  Key: "<redacted>",
+}`)
+	c.Assert(opts.Sdump(ed25519.NewKeyFromSeed(make([]byte, ed25519.SeedSize)).Public()), DumpEquals, `ed25519.PublicKey{
+  Algorithm: "Ed25519",
+  Bits: 256,
+  Key: "3b6a27bcceb6a42d62a3a8d02a6f0d73653215771de243a63ac048a18b59da29",
+}`)
+}
+
+// lockedBuffer is a buffer safe for concurrent use
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.buf.String()
+}
+
+// waitFor returns the content of the buffer once it contains n lines
+func (b *lockedBuffer) waitFor(n int) string {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) && strings.Count(b.String(), "\n") < n {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	return b.String()
+}
+
+func (ts *DumperSuite) TestRemoteWriter(c *C) {
+	ref := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	opts := Options{Now: func() time.Time { return ref }}
+	hostname, _ := os.Hostname()
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	out := &lockedBuffer{}
+	srv := &Server{Out: out}
+	done := make(chan error)
+	go func() { done <- srv.Serve(l) }()
+
+	fallback := &bytes.Buffer{}
+	w := &RemoteWriter{Addr: l.Addr().String(), Fallback: fallback, Options: opts}
+	defer w.Close()
+
+	defer func(previous func(...interface{})) { Dump = previous }(Dump)
+	Dump = w.Dump
+	_, _, line, _ := runtime.Caller(0)
+	Dump("foo", 42)
+	_, _ = w.Write([]byte("plain text"))
+
+	// the header is colored as well
+	c.Assert(strings.HasPrefix(out.waitFor(5), "\x1b["), Equals, true)
+	c.Assert(ansiSequences.ReplaceAllString(out.String(), ""), Equals, fmt.Sprintf(`%[1]s %[2]s[%[3]d] dumper_test.go:%[4]d
+"foo"
+42
+%[1]s %[2]s[%[3]d] dumper_test.go:%[5]d
+plain text
+`, ref.Local().Format("2006-01-02 15:04:05"), hostname, os.Getpid(), line+1, line+2))
+	c.Assert(fallback.String(), Equals, "")
+
+	// dumps of PHP applications are received as well
+	conn, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	_, _ = conn.Write([]byte(base64.StdEncoding.EncodeToString([]byte(testVarDumperPayload)) + "\n"))
+	c.Assert(ansiSequences.ReplaceAllString(strings.Join(strings.Split(out.waitFor(17), "\n")[5:], "\n"), ""), Equals, time.Unix(1893553445, 0).Format("2006-01-02 15:04:05")+` index.php:12
+array:3 [
+  "a" => 1
+  "b" => array:2 [
+    0 => true
+    1 => null
+  ]
+  "o" => App\Foo {#12
+    #name: "bar"
+    -secret: "abc"…3
+  }
+]
+`)
+
+	// other payloads are reported
+	_, _ = conn.Write([]byte("{\"dump\": \"foo\"}\n"))
+	c.Assert(strings.Contains(ansiSequences.ReplaceAllString(out.waitFor(18), ""), "ignored an invalid message from 127.0.0.1:"), Equals, true)
+	_ = conn.Close()
+
+	c.Assert(srv.Close(), IsNil)
+	c.Assert(<-done, IsNil)
+
+	// nothing is listening anymore
+	Dump("foo")
+	c.Assert(fallback.String(), Equals, "\"foo\"\n")
+}
+
+func (ts *DumperSuite) TestVarDumperPayload(c *C) {
+	ref := time.Date(2030, 1, 2, 3, 4, 5, 600000000, time.UTC)
+	payload := encodePayload(Message{Dump: "\x1b[1;38;5;113mfoo\x1b[m\n", File: "/app/main.go", Line: 12, Time: ref, Hostname: "web", PID: 42})
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSuffix(string(payload), "\n"))
+	c.Assert(err, IsNil)
+
+	// Symfony's server reads the Data, holding the dump without colors, and
+	// the timestamp and the source from the context
+	c.Assert(strings.HasPrefix(string(raw), `a:2:{i:0;O:39:"Symfony\Component\VarDumper\Cloner\Data":3:{`+
+		"s:45:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00data\";a:1:{i:0;a:1:{i:0;s:3:\"foo\";}}"), Equals, true)
+	c.Assert(strings.Contains(string(raw), `}i:1;a:6:{s:9:"timestamp";d:1893553445.6;s:6:"source";a:4:{s:4:"name";s:7:"main.go";s:4:"file";s:12:"/app/main.go";s:4:"line";i:12;s:12:"file_excerpt";b:0;}`), Equals, true)
+
+	msg, err := decodePayload(payload[:len(payload)-1])
+	c.Assert(err, IsNil)
+	c.Assert(msg.Time.Equal(ref), Equals, true)
+	msg.Time = ref
+	c.Assert(msg, DeepEquals, Message{Dump: "\x1b[1;38;5;113mfoo\x1b[m\n", File: "/app/main.go", Line: 12, Time: ref, Hostname: "web", PID: 42})
+
+	for _, invalid := range []string{"a:1:{i:0;N;}", "a:2:{i:0;N;i:1;a:0:{}}", "a:2:{i:0;s:5:\"foo\";}", "a:1:{i:0;r:3;}"} {
+		_, err := decodePayload([]byte(base64.StdEncoding.EncodeToString([]byte(invalid))))
+		c.Assert(err, NotNil, Commentf(invalid))
+	}
+}
+
+// testVarDumperPayload is the serialization sent by VarDumper for
+// dump(['a' => 1, 'b' => [true, null], 'o' => $foo]), $foo being an App\Foo
+// with a protected $name and a private $secret cut after 3 characters
+const testVarDumperPayload = "a:2:{i:0;O:39:\"Symfony\\Component\\VarDumper\\Cloner\\Data\":7:{s:45:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00data\";" +
+	"a:4:{i:0;a:1:{i:0;a:1:{i:2;i:1;}}i:1;a:3:{s:1:\"a\";i:1;s:1:\"b\";" +
+	"a:1:{i:1;i:2;}s:1:\"o\";O:39:\"Symfony\\Component\\VarDumper\\Cloner\\Stub\":8:{" +
+	"s:4:\"type\";i:4;s:5:\"class\";s:7:\"App\\Foo\";s:5:\"value\";N;s:3:\"cut\";" +
+	"i:0;s:6:\"handle\";i:12;s:8:\"refCount\";i:0;s:8:\"position\";i:3;" +
+	"s:4:\"attr\";a:0:{}}}i:2;a:2:{i:0;b:1;i:1;N;}i:3;a:2:{s:7:\"\x00*\x00name\";" +
+	"s:3:\"bar\";s:15:\"\x00App\\Foo\x00secret\";O:39:\"Symfony\\Component\\VarDumper\\Cloner\\Stub\":8:{" +
+	"s:4:\"type\";i:2;s:5:\"class\";i:2;s:5:\"value\";s:3:\"abc\";s:3:\"cut\";" +
+	"i:3;s:6:\"handle\";i:0;s:8:\"refCount\";i:0;s:8:\"position\";i:0;s:4:\"attr\";" +
+	"a:0:{}}}}s:49:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00position\";" +
+	"i:0;s:44:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00key\";i:0;" +
+	"s:49:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00maxDepth\";i:20;" +
+	"s:57:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00maxItemsPerDepth\";" +
+	"i:-1;s:54:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00useRefHandles\";" +
+	"i:-1;s:48:\"\x00Symfony\\Component\\VarDumper\\Cloner\\Data\x00context\";" +
+	"a:0:{}}i:1;a:3:{s:9:\"timestamp\";d:1893553445.5;s:6:\"source\";" +
+	"a:4:{s:4:\"name\";s:9:\"index.php\";s:4:\"file\";s:21:\"/app/public/index.php\";" +
+	"s:4:\"line\";i:12;s:12:\"file_excerpt\";b:0;}s:3:\"cli\";a:2:{s:12:\"command_line\";" +
+	"s:20:\"bin/console app:test\";s:10:\"identifier\";s:8:\"5f4d3c2b\";" +
+	"}}}"
+
+type Settings struct {
+	Name     string
+	Password string
+	Params   map[string]interface{}
+	Ports    []int
+}
+
+func (ts *DumperSuite) TestLimits(c *C) {
+	settings := Settings{
+		Name:     "app",
+		Password: "s3cr3t",
+		Params: map[string]interface{}{
+			"token":  "abc",
+			"nested": map[string]int{"a": 1},
+			"list":   []interface{}{1, 2, 3},
+		},
+		Ports: []int{80, 443, 8080, 8443},
+	}
+
+	opts := Options{Redact: []string{"password", "TOKEN"}}
+	c.Assert(opts.Sdump(settings), DumpEquals, `dumper.Settings{
+  Name: "app",
+  Password: "<redacted>",
+  Params: map[string]interface {}{"list": []interface {}{1, 2, 3,}, "nested": map[string]int{"a": 1,}, "token": "<redacted>",},
+  Ports: []int{80, 443, 8080, 8443,}, // len=4
+}`)
+
+	opts = Options{MaxDepth: 2, MaxItems: 2}
+	c.Assert(opts.Sdump(settings), DumpEquals, `dumper.Settings{
+  Name: "app",
+  Password: "s3cr3t",
+  Params: map[string]interface {}{"list": []interface {}{...}, "nested": map[string]int{...}, /* 1 more */},
+  Ports: []int{80, 443, /* 2 more */}, // len=4
+}`)
+
+	opts = Options{MaxDepth: 1}
+	c.Assert(opts.Sdump(settings), DumpEquals, `dumper.Settings{
+  Name: "app",
+  Password: "s3cr3t",
+  Params: map[string]interface {}{...},
+  Ports: []int{...},
+}`)
+
+	opts = Options{Multiline: true, MaxItems: 3}
+	c.Assert(opts.Sdump(settings.Params), DumpEquals, `map[string]interface {}{
+  "list": []interface {}{ // len=3
+    1,
+    2,
+    3,
+  },
+  "nested": map[string]int{
+    "a": 1,
+  },
+  "token": "abc",
+}`)
+	c.Assert(opts.Sdump(settings.Ports), DumpEquals, `[]int{ // len=4
+  80,
+  443,
+  8080,
+  /* 1 more */
+}`)
+}
+
+func (ts *DumperSuite) TestHTML(c *C) {
+	buf := &bytes.Buffer{}
+	FdumpHTML(buf, map[string]interface{}{"<b>": nil})
+	c.Assert(buf.String(), Equals, `<pre class="dumper"><span class="dumper-note">map</span>[<span class="dumper-meta">string</span>]<span class="dumper-meta">interface {}</span>{<span class="dumper-const">&#34;</span><span class="dumper-str">&lt;b&gt;</span><span class="dumper-const">&#34;</span>: <span class="dumper-ref">nil</span>,}</pre>
+`)
+}
+
+func (ts *DumperSuite) TestFormatter(c *C) {
+	account := &Settings{Name: "app", Ports: []int{80}}
+
+	c.Assert(fmt.Sprintf("%v", F(account)), Equals, `&dumper.Settings{Name: "app", Password: "", Params: nil, Ports: []int{80,},}`)
+	c.Assert(fmt.Sprintf("%s", F(int64(3))), Equals, `int64(3)`)
+	c.Assert(fmt.Sprintf("%+v", F(account)), DumpEquals, `&dumper.Settings{ // (0xXXXXXXXXXX)
+  Name: "app",
+  Password: "",
+  Params: nil, // map[string]interface {}
+  Ports: []int{80,}, // len=1
+}`)
+	c.Assert(fmt.Sprintf("%#v", F(account)), Equals, `&dumper.Settings{
+  Name: "app",
+  Password: "",
+  Params: nil,
+  Ports: []int{80,},
+}`)
+	c.Assert(fmt.Sprintf("% v", F([]int{1})), Equals, "[]\x1b[38;5;170mint\x1b[m{\x1b[1;38;5;38m1\x1b[m,}")
+	c.Assert(fmt.Sprintf("%v", Options{Redact: []string{"password"}}.F(Settings{Password: "s3cr3t"})), Equals, `dumper.Settings{Name: "", Password: "<redacted>", Params: nil, Ports: nil,}`)
+	// strings are escaped to keep their line breaks and indentation
+	c.Assert(fmt.Sprintf("%v", F(map[string]string{"k": "a {\n  b\n}"})), Equals, `map[string]string{"k": "a {\n  b\n}",}`)
+	c.Assert(fmt.Sprintf("%+v", F("a\n  b")), Equals, "\"a\n  b\"")
+
+	c.Assert(fmt.Sprintf("%x", F([]byte("Go"))), Equals, "476f")
+	c.Assert(fmt.Sprintf("%X", F([2]byte{0xca, 0xfe})), Equals, "CAFE")
+	c.Assert(fmt.Sprintf("%x", F("Go")), Equals, "476f")
+	c.Assert(fmt.Sprintf("%x", F(255)), Equals, "ff")
+	_, key, _ := ed25519.GenerateKey(nil)
+	c.Assert(fmt.Sprintf("%x", F(key)), Not(Matches), ".*"+hex.EncodeToString(key.Seed())+".*")
+	c.Assert(fmt.Sprintf("%x", F(key)), Equals, fmt.Sprintf("%v", F(key)))
+	c.Assert(fmt.Sprintf("%05d", F(42)), Equals, "00042")
+	c.Assert(fmt.Sprintf("%d", F("a")), Equals, "%!d(string=a)")
+}
+
+const testStacks = `goroutine 1 [running]:
+main.main()
+	/app/main.go:18 +0xcf
+
+goroutine 7 [chan receive, 3 minutes]:
+main.worker(0x1, {0xc0123, 0x5})
+	/app/worker.go:9 +0x25
+created by main.main in goroutine 1
+	/app/main.go:14 +0x45
+
+goroutine 8 [chan receive]:
+main.worker(0x2, {0xc0123, 0x5})
+	/app/worker.go:9 +0x25
+created by main.main in goroutine 1
+	/app/main.go:14 +0x45
+
+goroutine 9 [select]:
+net/http.(*persistConn).writeLoop(0xc0a4)
+	/usr/local/go/src/net/http/transport.go:2421 +0xe5
+created by net/http.(*Transport).dialConn in goroutine 1
+	/usr/local/go/src/net/http/transport.go:1777 +0x16f1
+`
+
+func (ts *DumperSuite) TestGoroutines(c *C) {
+	groups := parseGoroutines([]byte(testStacks))
+	c.Assert(Sdump(groups), DumpEquals, `dumper.GoroutineGroups{ // 4 goroutines, 3 groups
+  2 goroutines [chan receive]: // 7, 8
+    main.worker(*, {0xc0123, 0x5})
+      /app/worker.go:9
+    created by main.main
+      /app/main.go:14
+  1 goroutine [running]: // 1
+    main.main()
+      /app/main.go:18
+  1 goroutine [select]: // 9
+    net/http.(*persistConn).writeLoop(0xc0a4)
+      /usr/local/go/src/net/http/transport.go:2421
+    created by net/http.(*Transport).dialConn
+      /usr/local/go/src/net/http/transport.go:1777
+}`)
+
+	c.Assert(groups.filter([]string{"net/http"}), HasLen, 1)
+	c.Assert(groups.filter([]string{"main", "net/http"}), HasLen, 3)
+	c.Assert(groups.filter([]string{"net"}), HasLen, 0)
+
+	ch := make(chan struct{})
+	defer close(ch)
+	for i := 0; i < 3; i++ {
+		go func() { <-ch }()
+	}
+	// wait for the goroutines to block on the channel
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		groups = Goroutines("github.com/symfony-cli/dumper")
+		if groups[0].State == "chan receive" || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(len(groups) >= 2, Equals, true)
+	c.Assert(groups[0].IDs, HasLen, 3)
+	c.Assert(groups[0].State, Equals, "chan receive")
+	c.Assert(strings.HasPrefix(groups[0].Frames[0].Func, "github.com/symfony-cli/dumper.(*DumperSuite).TestGoroutines"), Equals, true)
+}
+
+type Replica struct {
+	Host   string
+	Port   int
+	weight int
+}
+
+type Config struct {
+	Database struct {
+		Replicas []*Replica
+	}
+	Labels map[string]string
+}
+
+func (ts *DumperSuite) TestSelect(c *C) {
+	cfg := Config{Labels: map[string]string{"env": "prod", "team name": "core", "token": "abc"}}
+	cfg.Database.Replicas = []*Replica{{"db1", 5432, 2}, {"db2", 5433, 1}}
+
+	c.Assert(Sdump(Select(cfg, ".Database.Replicas[*].Host")), DumpEquals, `dumper.Selection{ // 2 matches for .Database.Replicas[*].Host
+  .Database.Replicas[0].Host: "db1",
+  .Database.Replicas[1].Host: "db2",
+}`)
+	c.Assert(Sdump(Select(&cfg, ".Database.Replicas[0].weight")), DumpEquals, `dumper.Selection{ // 1 match for .Database.Replicas[0].weight
+  .Database.Replicas[0].weight: 2,
+}`)
+	c.Assert(Sdump(Select(cfg, `.Labels["team name"]`)), DumpEquals, `dumper.Selection{ // 1 match for .Labels["team name"]
+  .Labels["team name"]: "core",
+}`)
+	c.Assert(Options{Select: ".Labels.*", Redact: []string{"token"}}.Sdump(cfg), DumpEquals, `dumper.Selection{ // 3 matches for .Labels.*
+  .Labels.env: "prod",
+  .Labels["team name"]: "core",
+  .Labels.token: "<redacted>",
+}`)
+	c.Assert(Sdump(Select(cfg, ".Database.Replicas[2]")), DumpEquals, `<none> // no match for .Database.Replicas[2]`)
+	c.Assert(Sdump(Select(cfg, ".Labels[env")), DumpEquals, `<invalid> // invalid query: unterminated "[env" in ".Labels[env"`)
+	c.Assert(Sdump(Select(cfg, "Labels")), DumpEquals, `<invalid> // invalid query: unexpected 'L' in "Labels", expected "." or "["`)
+	c.Assert(Select(cfg, ".").Matches, HasLen, 1)
+
+	// values with a custom dumper are not walked through
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(err, IsNil)
+	c.Assert(Sdump(Select(ecKey, ".D")), DumpEquals, `<none> // no match for .D`)
+	_, edKey, _ := ed25519.GenerateKey(nil)
+	c.Assert(Select(map[string]interface{}{"key": edKey}, ".key[*]").Matches, HasLen, 0)
+	c.Assert(Select(map[string]interface{}{"key": edKey}, ".key").Matches, HasLen, 1)
+}
+
+type Service struct {
+	Name   string
+	Logger interface{}
+	mu     sync.Mutex
+	cache  map[string][]byte
+	Peers  []*Service
+	Meta   map[string]interface{}
+}
+
+func (ts *DumperSuite) TestFilters(c *C) {
+	svc := Service{
+		Name:   "api",
+		Logger: log.New(io.Discard, "", 0),
+		cache:  map[string][]byte{"k": []byte("v")},
+		Peers:  []*Service{{Name: "db", Meta: map[string]interface{}{"secret": 1}}},
+		Meta:   map[string]interface{}{"region": "eu", "secret": "s3cr3t"},
+	}
+
+	opts := Options{
+		ExcludeTypes: []interface{}{sync.Mutex{}, (*log.Logger)(nil)},
+		ExcludePaths: []string{"**.cache", ".Peers[*].Meta"},
+		Exclude: func(path string, v reflect.Value) bool {
+			return strings.HasSuffix(path, ".secret")
+		},
+	}
+	c.Assert(opts.Sdump(&svc), DumpEquals, `&dumper.Service{ // (0xXXXXXXXXXX)
+  Name: "api",
+  Logger: <excluded *log.Logger>,
+  mu: <excluded sync.Mutex>,
+  cache: <excluded map[string][]uint8>,
+  Peers: []*dumper.Service{&dumper.Service{ // (0xXXXXXXXXXX)
+      Name: "db",
+      Logger: nil,
+      mu: <excluded sync.Mutex>,
+      cache: <excluded map[string][]uint8>,
+      Peers: nil, // []*dumper.Service
+      Meta: <excluded map[string]interface {}>,
+    },}, // len=1
+  Meta: map[string]interface {}{"region": "eu", "secret": <excluded interface {}>,},
+}`)
+
+	var paths []string
+	Options{Exclude: func(path string, v reflect.Value) bool {
+		paths = append(paths, path)
+		return false
+	}}.Sdump(svc.Peers)
+	c.Assert(paths, DeepEquals, []string{"[0]", "[0].Name", "[0].Logger", "[0].mu", "[0].cache", "[0].Peers", "[0].Meta", "[0].Meta.secret"})
+}
+
+type Deployment struct {
+	Name      string
+	Replicas  int
+	Labels    map[string]string
+	Selector  []string
+	CreatedAt time.Time
+	Paused    bool
+	Owner     *Deployment
+	Spec      struct {
+		Image string
+		Args  []string
+	}
+}
+
+func (ts *DumperSuite) TestOmitZero(c *C) {
+	d := Deployment{Name: "api", Replicas: 3}
+	d.Spec.Image = "api:1.0"
+
+	c.Assert(Options{OmitZero: true}.Sdump(d), DumpEquals, `dumper.Deployment{
+  Name: "api",
+  Replicas: 3,
+  Spec: struct { Image string; Args []string }{ // anonymous struct
+    Image: "api:1.0",
+    // 1 zero field hidden
+  },
+  // 5 zero fields hidden
+}`)
+
+	d.CreatedAt = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	c.Assert(Options{OmitZero: true, noComments: true}.Sdump(Deployment{}), DumpEquals, `dumper.Deployment{
+}`)
+	c.Assert(strings.Contains(Options{OmitZero: true}.Sdump(d), "CreatedAt: "), Equals, true)
+
+	// panicking IsZero() methods fall back to the zero value of the type
+	limit := 2
+	c.Assert(Options{OmitZero: true}.Sdump(struct {
+		Quota  Quota
+		Shared Quota
+	}{Shared: Quota{limit: &limit}}), DumpEquals, `struct { Quota dumper.Quota; Shared dumper.Quota }{ // anonymous struct
+  Shared: dumper.Quota{
+    limit: &2, // (0xXXXXXXXXXX)
+  },
+  // 1 zero field hidden
+}`)
+}
+
+type Quota struct {
+	limit *int
+}
+
+func (q Quota) IsZero() bool {
+	return *q.limit == 0
+}
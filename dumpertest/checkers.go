@@ -0,0 +1,131 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumpertest
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/symfony-cli/dumper"
+	check "gopkg.in/check.v1"
+)
+
+// Colors enables colors in the differences reported on failure. It is
+// disabled when the NO_COLOR environment variable is set.
+var Colors = os.Getenv("NO_COLOR") == ""
+
+// TestingT is the subset of testing.TB used by DumpEqual, implemented by
+// testify's assert.TestingT as well.
+type TestingT interface {
+	Errorf(format string, args ...interface{})
+}
+
+type dumpEqualsChecker struct {
+	*check.CheckerInfo
+}
+
+// DumpEquals is a gopkg.in/check.v1 checker verifying that a dump, or the
+// dump of a non-string value, equals the expected one once normalized:
+//
+//	c.Assert(dumper.Sdump(v), dumpertest.DumpEquals, `[]int{1,}`)
+var DumpEquals check.Checker = &dumpEqualsChecker{
+	&check.CheckerInfo{Name: "DumpEquals", Params: []string{"obtained", "expected"}},
+}
+
+func (checker *dumpEqualsChecker) Check(params []interface{}, names []string) (bool, string) {
+	expected, ok := params[1].(string)
+	if !ok {
+		return false, "expected must be a string"
+	}
+
+	obtained := dumpOf(params[0])
+	if obtained == Normalize(expected) {
+		return true, ""
+	}
+
+	return false, "Difference (-expected +obtained):\n" + colorDiff(Normalize(expected), obtained)
+}
+
+// DumpEqual asserts that a dump, or the dump of a non-string value, equals
+// the expected one once normalized. It follows the conventions of testify's
+// assert package.
+func DumpEqual(t TestingT, obtained interface{}, expected string, msgAndArgs ...interface{}) bool {
+	if h, ok := t.(interface{ Helper() }); ok {
+		h.Helper()
+	}
+
+	dump := dumpOf(obtained)
+	if dump == Normalize(expected) {
+		return true
+	}
+
+	t.Errorf("%sdumps are not equal (-expected +obtained):\n%s", messageOf(msgAndArgs), colorDiff(Normalize(expected), dump))
+
+	return false
+}
+
+// messageOf formats the optional message like testify: a single value, or a
+// format followed by its arguments
+func messageOf(msgAndArgs []interface{}) string {
+	if len(msgAndArgs) == 0 {
+		return ""
+	}
+
+	format, ok := msgAndArgs[0].(string)
+	if !ok {
+		return fmt.Sprintf("%+v\n", msgAndArgs[0])
+	}
+	if len(msgAndArgs) == 1 {
+		return format + "\n"
+	}
+
+	return fmt.Sprintf(format, msgAndArgs[1:]...) + "\n"
+}
+
+// dumpOf returns the normalized dump, values other than strings being
+// dumped first
+func dumpOf(v interface{}) string {
+	if str, ok := v.(string); ok {
+		return Normalize(str)
+	}
+
+	return Normalize(dumper.Sdump(v))
+}
+
+// colorDiff returns the differences, colored unless disabled
+func colorDiff(a, b string) string {
+	diff := Diff(a, b)
+	if !Colors {
+		return diff
+	}
+
+	lines := strings.SplitAfter(diff, "\n")
+	for i, line := range lines {
+		switch {
+		case strings.HasPrefix(line, "- "):
+			lines[i] = "\033[31m" + strings.TrimSuffix(line, "\n") + "\033[m\n"
+		case strings.HasPrefix(line, "+ "):
+			lines[i] = "\033[32m" + strings.TrimSuffix(line, "\n") + "\033[m\n"
+		}
+	}
+
+	return strings.Join(lines, "")
+}
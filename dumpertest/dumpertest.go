@@ -0,0 +1,111 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+// Package dumpertest helps using the dumper in tests.
+package dumpertest
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/symfony-cli/dumper"
+)
+
+var (
+	logsMu sync.Mutex
+	logs   = map[testing.TB]*[]string{}
+)
+
+// Log dumps the values to the test log, only when the test fails. Values are
+// dumped right away, so later changes are not reflected.
+func Log(t testing.TB, values ...interface{}) {
+	t.Helper()
+
+	dump := fmt.Sprintf("%s:\n%s", callerLine(), dumper.Sdump(values...))
+
+	logsMu.Lock()
+	defer logsMu.Unlock()
+
+	if buffered, ok := logs[t]; ok {
+		*buffered = append(*buffered, dump)
+		return
+	}
+
+	buffered := &[]string{dump}
+	logs[t] = buffered
+	// cleanup functions are called in reverse order, so a single one emits
+	// all the dumps in order
+	t.Cleanup(func() {
+		logsMu.Lock()
+		delete(logs, t)
+		logsMu.Unlock()
+
+		if !t.Failed() {
+			return
+		}
+		for _, dump := range *buffered {
+			t.Log(dump)
+		}
+	})
+}
+
+// Equal checks that got and want are deeply equal, and reports the
+// differences between their dumps otherwise.
+func Equal(t testing.TB, got, want interface{}) bool {
+	t.Helper()
+
+	if reflect.DeepEqual(got, want) {
+		return true
+	}
+
+	// one element per line makes for more readable differences
+	opts := dumper.Options{Multiline: true}
+	gotDump, wantDump := Normalize(opts.Sdump(got)), Normalize(opts.Sdump(want))
+	if gotDump == wantDump {
+		t.Errorf("%s: values are not equal but their dumps are identical:\n%s", callerLine(), gotDump)
+		return false
+	}
+
+	t.Errorf("%s: values are not equal (-want +got):\n%s", callerLine(), colorDiff(wantDump, gotDump))
+	return false
+}
+
+var addressRegexp = regexp.MustCompile(`0x[0-9a-f]{8,12}`)
+
+// Normalize replaces the memory addresses of a dump by a placeholder and
+// removes the trailing new lines, so that dumps can be compared.
+func Normalize(dump string) string {
+	return strings.TrimRight(addressRegexp.ReplaceAllString(dump, "0xXXXXXXXXXX"), "\n")
+}
+
+// callerLine returns the file:line of the caller of the dumpertest function
+func callerLine() string {
+	_, file, line, ok := runtime.Caller(2)
+	if !ok {
+		return "???"
+	}
+
+	return fmt.Sprintf("%s:%d", filepath.Base(file), line)
+}
@@ -0,0 +1,141 @@
+package dumpertest_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/symfony-cli/dumper/dumpertest"
+	. "gopkg.in/check.v1"
+)
+
+type DumperTestSuite struct{}
+
+var _ = Suite(&DumperTestSuite{})
+
+func Test(t *testing.T) { TestingT(t) }
+
+func (s *DumperTestSuite) SetUpSuite(c *C) {
+	dumpertest.Colors = false
+}
+
+// fakeT records what is logged and reported
+type fakeT struct {
+	testing.TB
+	failed   bool
+	logs     []string
+	errors   []string
+	cleanups []func()
+}
+
+func (t *fakeT) Helper() {}
+
+func (t *fakeT) Failed() bool { return t.failed }
+
+func (t *fakeT) Cleanup(f func()) { t.cleanups = append(t.cleanups, f) }
+
+func (t *fakeT) Log(args ...interface{}) { t.logs = append(t.logs, fmt.Sprint(args...)) }
+
+func (t *fakeT) Errorf(format string, args ...interface{}) {
+	t.failed = true
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *fakeT) runCleanups() {
+	for i := len(t.cleanups) - 1; i >= 0; i-- {
+		t.cleanups[i]()
+	}
+}
+
+type point struct {
+	X, Y int
+}
+
+func (s *DumperTestSuite) TestLog(c *C) {
+	t := &fakeT{}
+	p := &point{X: 1}
+	dumpertest.Log(t, p)
+	p.X = 2
+	dumpertest.Log(t, "second", 3)
+	t.runCleanups()
+	c.Assert(t.logs, HasLen, 0)
+
+	t = &fakeT{}
+	dumpertest.Log(t, p)
+	dumpertest.Log(t, "second")
+	t.failed = true
+	t.runCleanups()
+	c.Assert(t.logs, HasLen, 2)
+	c.Assert(dumpertest.Normalize(t.logs[0]), Equals, `dumpertest_test.go:63:
+&dumpertest_test.point{ // (0xXXXXXXXXXX)
+  X: 2,
+  Y: 0,
+}`)
+	c.Assert(t.logs[1], Equals, `dumpertest_test.go:64:
+"second"`)
+}
+
+func (s *DumperTestSuite) TestEqual(c *C) {
+	t := &fakeT{}
+	c.Assert(dumpertest.Equal(t, &point{X: 1}, &point{X: 1}), Equals, true)
+	c.Assert(t.errors, HasLen, 0)
+
+	c.Assert(dumpertest.Equal(t, []point{{X: 1, Y: 2}}, []point{{X: 1, Y: 3}}), Equals, false)
+	c.Assert(t.errors, DeepEquals, []string{`dumpertest_test.go:82: values are not equal (-want +got):
+  []dumpertest_test.point{ // len=1
+    dumpertest_test.point{
+      X: 1,
+-     Y: 3,
++     Y: 2,
+    },
+  }
+`})
+
+	t = &fakeT{}
+	c.Assert(dumpertest.Equal(t, int64(1), 1), Equals, false)
+	c.Assert(t.errors, HasLen, 1)
+	c.Assert(t.errors[0], Equals, `dumpertest_test.go:94: values are not equal (-want +got):
+- 1
++ int64(1)
+`)
+}
+
+func (s *DumperTestSuite) TestDiff(c *C) {
+	c.Assert(dumpertest.Diff("a\nb\nc", "a\nc\nd"), Equals, "  a\n- b\n  c\n+ d\n")
+	c.Assert(dumpertest.Diff("", "a"), Equals, "- \n+ a\n")
+}
+
+func (s *DumperTestSuite) TestDumpEquals(c *C) {
+	c.Assert(`&dumpertest_test.point{ // (0xc000012345)
+  X: 1,
+  Y: 0,
+}
+`, dumpertest.DumpEquals, `&dumpertest_test.point{ // (0xXXXXXXXXXX)
+  X: 1,
+  Y: 0,
+}`)
+	c.Assert([]int{1}, dumpertest.DumpEquals, `[]int{1,} // len=1`)
+
+	result, message := dumpertest.DumpEquals.Check([]interface{}{point{X: 2}, "dumpertest_test.point{\n  X: 1,\n  Y: 0,\n}"}, nil)
+	c.Assert(result, Equals, false)
+	c.Assert(message, Equals, `Difference (-expected +obtained):
+  dumpertest_test.point{
+-   X: 1,
++   X: 2,
+    Y: 0,
+  }
+`)
+
+	dumpertest.Colors = true
+	defer func() { dumpertest.Colors = false }()
+	_, message = dumpertest.DumpEquals.Check([]interface{}{"a", "b"}, nil)
+	c.Assert(message, Equals, "Difference (-expected +obtained):\n\x1b[31m- b\x1b[m\n\x1b[32m+ a\x1b[m\n")
+}
+
+func (s *DumperTestSuite) TestDumpEqual(c *C) {
+	t := &fakeT{}
+	c.Assert(dumpertest.DumpEqual(t, map[string]int{"a": 1}, `map[string]int{"a": 1,}`), Equals, true)
+	c.Assert(t.errors, HasLen, 0)
+
+	c.Assert(dumpertest.DumpEqual(t, "1", "2", "check %s", "value"), Equals, false)
+	c.Assert(t.errors, DeepEquals, []string{"check value\ndumps are not equal (-expected +obtained):\n- 2\n+ 1\n"})
+}
@@ -0,0 +1,143 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// excludedPaths compiles the ExcludePaths globs: "*" matches a single step
+// of a path, and "**" any number of them
+func (o Options) excludedPaths() []*regexp.Regexp {
+	var res []*regexp.Regexp
+	for _, glob := range o.ExcludePaths {
+		var b strings.Builder
+		b.WriteString("^")
+		for i, part := range strings.Split(glob, "**") {
+			if i > 0 {
+				b.WriteString(".*")
+			}
+			b.WriteString(strings.ReplaceAll(regexp.QuoteMeta(part), `\*`, `[^.\[]*`))
+		}
+		b.WriteString("$")
+		res = append(res, regexp.MustCompile(b.String()))
+	}
+
+	return res
+}
+
+// excludedType returns the type of the value matching ExcludeTypes, if any
+func (o Options) excludedType(v reflect.Value) reflect.Type {
+	if len(o.ExcludeTypes) == 0 {
+		return nil
+	}
+
+	types := []reflect.Type{v.Type()}
+	if v.Kind() == reflect.Interface && !v.IsNil() {
+		types = append(types, v.Elem().Type())
+	}
+	for _, excluded := range o.ExcludeTypes {
+		for _, typ := range types {
+			if reflect.TypeOf(excluded) == typ {
+				return typ
+			}
+		}
+	}
+
+	return nil
+}
+
+// filtering checks whether some values might be excluded
+func (o Options) filtering() bool {
+	return len(o.ExcludeTypes) > 0 || len(o.ExcludePaths) > 0 || o.Exclude != nil
+}
+
+// dumpValAt dumps a field or an element of the current value, unless it is
+// excluded. step is appended to the path of the current value, like ".Name"
+// or "[0]".
+func (s *state) dumpValAt(step string, v reflect.Value) {
+	if !s.opts.filtering() || !v.IsValid() {
+		s.dumpVal(v)
+		return
+	}
+
+	previous := s.path
+	s.path += step
+	defer func() { s.path = previous }()
+
+	if typ := s.excluded(v); typ != nil {
+		s.printfStyle("ref", "<excluded %s>", typ)
+		return
+	}
+
+	s.dumpVal(v)
+}
+
+// excluded returns the type displayed in place of the value when it is
+// excluded, nil otherwise
+func (s *state) excluded(v reflect.Value) reflect.Type {
+	if typ := s.opts.excludedType(v); typ != nil {
+		return typ
+	}
+
+	for _, glob := range s.excludedPaths {
+		if glob.MatchString(s.path) {
+			return v.Type()
+		}
+	}
+
+	if s.opts.Exclude != nil && s.opts.Exclude(s.path, v) {
+		return v.Type()
+	}
+
+	return nil
+}
+
+// fieldStep returns the path step of a struct field; labels already being
+// paths, like the ones of selections, are kept as is
+func fieldStep(name string) string {
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "[") {
+		return name
+	}
+
+	return "." + name
+}
+
+// indexStep returns the path step of a slice or array element, only
+// computed when filtering
+func (s *state) indexStep(i int) string {
+	if !s.opts.filtering() {
+		return ""
+	}
+
+	return fmt.Sprintf("[%d]", i)
+}
+
+// keyStep returns the path step of a map entry, only computed when filtering
+func (s *state) keyStep(k reflect.Value) string {
+	if !s.opts.filtering() {
+		return ""
+	}
+
+	return keyPath(k, fmt.Sprint(k))
+}
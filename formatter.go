@@ -0,0 +1,140 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+type formatter struct {
+	v      interface{}
+	opts   Options
+	caller string
+}
+
+// F wraps a value so that it is dumped when formatted by the fmt package,
+// making the dumper usable with Printf-like functions and loggers:
+//
+//	%v   on a single line, without comments
+//	%+v  on several lines, with comments
+//	%#v  on several lines, without comments, closer to Go syntax
+//	%x   as hexadecimal for strings, byte slices and arrays (%X in upper case),
+//	     unless they have a custom dumper, in which case they are dumped as with %v
+//
+// The space flag, like in "% v", enables colors. Other verbs format the
+// value as fmt does.
+func F(v interface{}) fmt.Formatter {
+	return DefaultOptions.F(v)
+}
+
+// F wraps a value so that it is dumped with the options when formatted by
+// the fmt package, see the F function.
+func (o Options) F(v interface{}) fmt.Formatter {
+	return formatter{v: v, opts: o, caller: lastCaller()}
+}
+
+// Format implements fmt.Formatter.
+func (f formatter) Format(st fmt.State, verb rune) {
+	opts := f.opts
+	opts.caller = f.caller
+
+	// the bytes of values with a custom dumper might be secret, like the
+	// ones of private keys, so they are dumped as with %v instead
+	if (verb == 'x' || verb == 'X') && f.v != nil && hasCustomDumper(reflect.TypeOf(f.v)) {
+		verb = 'v'
+	}
+
+	switch verb {
+	case 'v', 's':
+		switch {
+		case st.Flag('#'):
+			opts.noComments = true
+		case !st.Flag('+'):
+			opts.singleLine, opts.noComments = true, true
+		}
+
+		styles := defaultStyles
+		if st.Flag(' ') {
+			styles = colorStyles
+		}
+
+		buf := &bytes.Buffer{}
+		fdump(buf, opts, styles, f.v)
+		_, _ = st.Write(buf.Bytes())
+
+	case 'x', 'X':
+		if b, ok := bytesOf(f.v); ok {
+			str := hex.EncodeToString(b)
+			if verb == 'X' {
+				str = strings.ToUpper(str)
+			}
+			_, _ = st.Write([]byte(str))
+			return
+		}
+		fmt.Fprintf(st, formatDirective(st, verb), f.v)
+
+	default:
+		fmt.Fprintf(st, formatDirective(st, verb), f.v)
+	}
+}
+
+// bytesOf returns the bytes of strings, and byte slices and arrays
+func bytesOf(v interface{}) ([]byte, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return []byte(rv.String()), true
+	case reflect.Slice, reflect.Array:
+		if rv.Type().Elem().Kind() != reflect.Uint8 {
+			return nil, false
+		}
+		b := make([]byte, rv.Len())
+		for i := range b {
+			b[i] = byte(rv.Index(i).Uint())
+		}
+		return b, true
+	}
+
+	return nil, false
+}
+
+// formatDirective rebuilds the directive being formatted, flags, width and
+// precision included
+func formatDirective(st fmt.State, verb rune) string {
+	directive := "%"
+	for _, flag := range "+-# 0" {
+		if st.Flag(int(flag)) {
+			directive += string(flag)
+		}
+	}
+	if width, ok := st.Width(); ok {
+		directive += strconv.Itoa(width)
+	}
+	if precision, ok := st.Precision(); ok {
+		directive += "." + strconv.Itoa(precision)
+	}
+
+	return directive + string(verb)
+}
@@ -2,11 +2,14 @@ module github.com/symfony-cli/dumper
 
 go 1.17
 
-require gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
+require (
+	github.com/andybalholm/brotli v1.1.1
+	github.com/pkg/errors v0.9.1
+	gopkg.in/check.v1 v1.0.0-20201130134442-10cb98267c6c
+)
 
 require (
 	github.com/kr/pretty v0.3.1 // indirect
 	github.com/kr/text v0.2.0 // indirect
-	github.com/pkg/errors v0.9.1 // indirect
 	github.com/rogpeppe/go-internal v1.9.0 // indirect
 )
@@ -0,0 +1,305 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// GoroutineGroups are goroutines grouped by identical stacks, as returned by
+// Goroutines.
+type GoroutineGroups []GoroutineGroup
+
+// GoroutineGroup are goroutines in the same state with the same stack.
+type GoroutineGroup struct {
+	IDs       []int
+	State     string
+	Frames    []StackFrame
+	CreatedBy *StackFrame
+}
+
+// StackFrame is a function call of a goroutine stack. Args are the argument
+// words, "*" standing for the words differing between the goroutines of a
+// group.
+type StackFrame struct {
+	Func string
+	Args []string
+	File string
+	Line int
+}
+
+var (
+	goroutineHeaderRegexp = regexp.MustCompile(`^goroutine (\d+)(?: [^\[]*)? \[(.*)\]:$`)
+	frameCallRegexp       = regexp.MustCompile(`^(.+)\((.*)\)$`)
+	frameLocationRegexp   = regexp.MustCompile(`^\t(.+):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	waitDurationRegexp    = regexp.MustCompile(`^\d+ minutes?$`)
+)
+
+// Goroutines captures the stacks of all the goroutines and groups the
+// identical ones. When packages are given, only the goroutines with a frame
+// in one of them are kept.
+//
+//	dumper.FdumpColor(os.Stderr, dumper.Goroutines("github.com/acme/app"))
+func Goroutines(packages ...string) GoroutineGroups {
+	buf := make([]byte, 64*1024)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			buf = buf[:n]
+			break
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+
+	return parseGoroutines(buf).filter(packages)
+}
+
+// parseGoroutines parses the output of runtime.Stack
+func parseGoroutines(stack []byte) GoroutineGroups {
+	var groups GoroutineGroups
+	index := map[string]int{}
+
+	var current *GoroutineGroup
+	var pending *StackFrame
+	var createdBy bool
+	flush := func() {
+		if current == nil {
+			return
+		}
+		key := current.key()
+		if i, ok := index[key]; ok {
+			groups[i].merge(*current)
+		} else {
+			index[key] = len(groups)
+			groups = append(groups, *current)
+		}
+		current = nil
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(stack))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if m := goroutineHeaderRegexp.FindStringSubmatch(line); m != nil {
+			flush()
+			id, _ := strconv.Atoi(m[1])
+			current = &GoroutineGroup{IDs: []int{id}, State: goroutineState(m[2])}
+			continue
+		}
+		if current == nil || line == "" {
+			continue
+		}
+
+		if m := frameLocationRegexp.FindStringSubmatch(line); m != nil && pending != nil {
+			pending.File = m[1]
+			pending.Line, _ = strconv.Atoi(m[2])
+			if createdBy {
+				current.CreatedBy = pending
+			} else {
+				current.Frames = append(current.Frames, *pending)
+			}
+			pending, createdBy = nil, false
+			continue
+		}
+
+		if strings.HasPrefix(line, "created by ") {
+			fn := strings.TrimPrefix(line, "created by ")
+			if i := strings.Index(fn, " in goroutine "); i >= 0 {
+				fn = fn[:i]
+			}
+			pending, createdBy = &StackFrame{Func: fn}, true
+			continue
+		}
+
+		if m := frameCallRegexp.FindStringSubmatch(line); m != nil {
+			pending = &StackFrame{Func: m[1]}
+			if m[2] != "" {
+				pending.Args = strings.Split(m[2], ", ")
+			}
+		}
+	}
+	flush()
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if len(groups[i].IDs) != len(groups[j].IDs) {
+			return len(groups[i].IDs) > len(groups[j].IDs)
+		}
+		return groups[i].IDs[0] < groups[j].IDs[0]
+	})
+
+	return groups
+}
+
+// goroutineState removes the wait duration from the state, so that it
+// does not prevent grouping
+func goroutineState(state string) string {
+	var parts []string
+	for _, part := range strings.Split(state, ", ") {
+		if !waitDurationRegexp.MatchString(part) {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// key identifies the group of the goroutine, arguments aside
+func (g *GoroutineGroup) key() string {
+	var b strings.Builder
+	b.WriteString(g.State)
+	for _, f := range append(g.Frames, g.createdBy()...) {
+		fmt.Fprintf(&b, "\n%s %s:%d", f.Func, f.File, f.Line)
+	}
+
+	return b.String()
+}
+
+func (g *GoroutineGroup) createdBy() []StackFrame {
+	if g.CreatedBy == nil {
+		return nil
+	}
+
+	return []StackFrame{*g.CreatedBy}
+}
+
+// merge adds the goroutine to the group, the argument words differing
+// being replaced by "*"
+func (g *GoroutineGroup) merge(other GoroutineGroup) {
+	g.IDs = append(g.IDs, other.IDs...)
+	for i := range g.Frames {
+		args, otherArgs := g.Frames[i].Args, other.Frames[i].Args
+		if len(args) != len(otherArgs) {
+			g.Frames[i].Args = []string{"*"}
+			continue
+		}
+		for j := range args {
+			if args[j] != otherArgs[j] {
+				args[j] = "*"
+			}
+		}
+	}
+}
+
+// mentions checks whether one of the frames is a function of the package
+func (g *GoroutineGroup) mentions(pkg string) bool {
+	for _, f := range append(g.Frames, g.createdBy()...) {
+		if strings.HasPrefix(f.Func, pkg+".") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (groups GoroutineGroups) filter(packages []string) GoroutineGroups {
+	if len(packages) == 0 {
+		return groups
+	}
+
+	var filtered GoroutineGroups
+	for _, g := range groups {
+		for _, pkg := range packages {
+			if g.mentions(pkg) {
+				filtered = append(filtered, g)
+				break
+			}
+		}
+	}
+
+	return filtered
+}
+
+// Dump implements Dumpable.
+func (groups GoroutineGroups) Dump(s State) {
+	count := 0
+	for _, g := range groups {
+		count += len(g.IDs)
+	}
+	s.AddComment(fmt.Sprintf("%d goroutines, %d groups", count, len(groups)))
+
+	for _, g := range groups {
+		ids := make([]string, len(g.IDs))
+		for i, id := range g.IDs {
+			ids[i] = strconv.Itoa(id)
+		}
+
+		s.Pad()
+		styled(s, "num", "%d", len(g.IDs))
+		if len(g.IDs) == 1 {
+			_, _ = s.Write([]byte(" goroutine ["))
+		} else {
+			_, _ = s.Write([]byte(" goroutines ["))
+		}
+		styled(s, "meta", "%s", g.State)
+		_, _ = s.Write([]byte("]:"))
+		writeComments(s, strings.Join(ids, ", "))
+		_, _ = s.Write([]byte("\n"))
+
+		s.DepthDown()
+		for _, f := range g.Frames {
+			dumpStackFrame(s, f, "")
+		}
+		if g.CreatedBy != nil {
+			dumpStackFrame(s, *g.CreatedBy, "created by ")
+		}
+		s.DepthUp()
+	}
+}
+
+func dumpStackFrame(s State, f StackFrame, prefix string) {
+	s.Pad()
+	_, _ = s.Write([]byte(prefix))
+	styled(s, "note", "%s", f.Func)
+	if prefix == "" {
+		_, _ = s.Write([]byte("("))
+		for i, arg := range f.Args {
+			if i > 0 {
+				_, _ = s.Write([]byte(", "))
+			}
+			styled(s, "num", "%s", arg)
+		}
+		_, _ = s.Write([]byte(")"))
+	}
+	_, _ = s.Write([]byte("\n"))
+
+	s.DepthDown()
+	s.Pad()
+	styled(s, "ref", "%s:%d", f.File, f.Line)
+	_, _ = s.Write([]byte("\n"))
+	s.DepthUp()
+}
+
+// styled writes with the style of the dump for the states of the package
+func styled(s State, style, format string, args ...interface{}) {
+	if ss, ok := s.(*state); ok {
+		ss.printfStyle(style, format, args...)
+		return
+	}
+
+	_, _ = fmt.Fprintf(s, format, args...)
+}
@@ -0,0 +1,82 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"html"
+	"io"
+	"regexp"
+)
+
+// HTMLStylesheet is the CSS matching the colors of FdumpColor for the HTML
+// produced by FdumpHTML.
+const HTMLStylesheet = `pre.dumper { background: #18171b; color: #ff8700; padding: 5px 10px; }
+pre.dumper .dumper-num, pre.dumper .dumper-const { font-weight: bold; }
+pre.dumper .dumper-num, pre.dumper .dumper-note, pre.dumper .dumper-index { color: #00afd7; }
+pre.dumper .dumper-const { color: #ff8700; }
+pre.dumper .dumper-str { color: #87d75f; font-weight: bold; }
+pre.dumper .dumper-key { color: #87d75f; }
+pre.dumper .dumper-ref { color: #8a8a8a; }
+pre.dumper .dumper-meta { color: #d75fd7; }
+`
+
+// htmlStyles marks the styled text with the name of the style, so that
+// FdumpHTML can turn the marks into CSS classes. The name is followed by an
+// underscore as the styled text might start with a letter.
+var htmlStyles = map[string]string{}
+
+// htmlMarks matches the text styled with htmlStyles
+var htmlMarks = regexp.MustCompile("\033\\[([a-z]+)_m((?s:.*?))\033\\[m")
+
+func init() {
+	for name := range colorStyles {
+		htmlStyles[name] = name + "_"
+	}
+}
+
+// FdumpHTML writes the values as HTML, in a pre element whose spans have
+// classes styled by HTMLStylesheet.
+func (o Options) FdumpHTML(out io.Writer, values ...interface{}) {
+	buf := &bytes.Buffer{}
+	fdump(buf, o, htmlStyles, values...)
+	dump := buf.String()
+
+	buf.Reset()
+	buf.WriteString(`<pre class="dumper">`)
+	last := 0
+	for _, m := range htmlMarks.FindAllStringSubmatchIndex(dump, -1) {
+		buf.WriteString(html.EscapeString(dump[last:m[0]]))
+		buf.WriteString(`<span class="dumper-` + dump[m[2]:m[3]] + `">`)
+		buf.WriteString(html.EscapeString(dump[m[4]:m[5]]))
+		buf.WriteString(`</span>`)
+		last = m[1]
+	}
+	buf.WriteString(html.EscapeString(dump[last:]))
+	buf.WriteString("</pre>\n")
+
+	_, _ = out.Write(buf.Bytes())
+}
+
+// FdumpHTML writes the values as HTML, in a pre element whose spans have
+// classes styled by HTMLStylesheet.
+func FdumpHTML(out io.Writer, values ...interface{}) {
+	DefaultOptions.FdumpHTML(out, values...)
+}
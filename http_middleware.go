@@ -0,0 +1,207 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"path"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// MiddlewareOptions configures the requests dumped by Middleware.
+type MiddlewareOptions struct {
+	// Out is where requests and responses are dumped. If nil, os.Stderr
+	// is used.
+	Out io.Writer
+	// Options are used to dump requests and responses. Response bodies are
+	// captured up to Options.MaxBytes.
+	Options Options
+	// Methods restricts the dumps to the requests using one of these
+	// methods. All requests are dumped if empty.
+	Methods []string
+	// Paths restricts the dumps to the requests whose path matches one of
+	// these patterns, using the path.Match syntax. All requests are dumped
+	// if empty.
+	Paths []string
+}
+
+func (o MiddlewareOptions) matches(r *http.Request) bool {
+	if len(o.Methods) > 0 {
+		found := false
+		for _, method := range o.Methods {
+			if strings.EqualFold(method, r.Method) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+
+	if len(o.Paths) > 0 {
+		for _, pattern := range o.Paths {
+			if ok, _ := path.Match(pattern, r.URL.Path); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+// Middleware dumps the requests handled by next, and the responses it sends:
+//
+//	http.ListenAndServe(":8000", dumper.Middleware(mux, dumper.MiddlewareOptions{}))
+func Middleware(next http.Handler, opts MiddlewareOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !opts.matches(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		id := atomic.AddUint64(&lastExchangeID, 1)
+		dumpExchange(opts.Out, opts.Options, fmt.Sprintf("<-- #%d %s %s", id, r.Method, r.URL), r)
+
+		rw := &responseRecorder{ResponseWriter: w, maxBytes: opts.Options.maxBytes()}
+		start := time.Now()
+		if _, ok := w.(http.Hijacker); ok {
+			next.ServeHTTP(&hijackableRecorder{rw}, r)
+		} else {
+			next.ServeHTTP(rw, r)
+		}
+		elapsed := time.Since(start)
+
+		resp := rw.response(r)
+		dumpExchange(opts.Out, opts.Options, fmt.Sprintf("--> #%d %s (%s)", id, resp.Status, elapsed), resp)
+	})
+}
+
+// responseRecorder captures the status, headers and the beginning of the body
+// of a response while it is sent
+type responseRecorder struct {
+	http.ResponseWriter
+
+	maxBytes int
+	status   int
+	header   http.Header
+	body     bytes.Buffer
+	written  int64
+	hijacked bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	// informational responses are followed by the final one
+	if status >= 100 && status < 200 && status != http.StatusSwitchingProtocols {
+		r.ResponseWriter.WriteHeader(status)
+		return
+	}
+
+	if r.status == 0 {
+		r.status = status
+		r.header = r.ResponseWriter.Header().Clone()
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(p []byte) (int, error) {
+	if r.status == 0 {
+		r.WriteHeader(http.StatusOK)
+	}
+
+	if r.maxBytes < 0 {
+		r.body.Write(p)
+	} else if left := r.maxBytes - r.body.Len(); left > 0 {
+		if len(p) < left {
+			left = len(p)
+		}
+		r.body.Write(p[:left])
+	}
+
+	n, err := r.ResponseWriter.Write(p)
+	r.written += int64(n)
+
+	return n, err
+}
+
+func (r *responseRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap allows http.ResponseController to access the original writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// hijackableRecorder is a responseRecorder for the writers implementing
+// http.Hijacker, like the ones of HTTP/1 connections, so that handlers
+// upgrading connections, to WebSocket for instance, keep working
+type hijackableRecorder struct {
+	*responseRecorder
+}
+
+func (r *hijackableRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	conn, rw, err := r.ResponseWriter.(http.Hijacker).Hijack()
+	if err == nil {
+		r.hijacked = true
+	}
+
+	return conn, rw, err
+}
+
+func (r *responseRecorder) response(req *http.Request) *http.Response {
+	status, header := r.status, r.header
+	if status == 0 {
+		status, header = http.StatusOK, r.ResponseWriter.Header().Clone()
+		// the response is written by the handler on the hijacked connection
+		if r.hijacked {
+			status = http.StatusSwitchingProtocols
+		}
+	}
+
+	if header == nil {
+		header = http.Header{}
+	}
+	if header.Get("Content-Type") == "" && r.body.Len() > 0 {
+		header.Set("Content-Type", http.DetectContentType(r.body.Bytes()))
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         req.Proto,
+		ProtoMajor:    req.ProtoMajor,
+		ProtoMinor:    req.ProtoMinor,
+		Header:        header,
+		ContentLength: r.written,
+		Body:          io.NopCloser(bytes.NewReader(r.body.Bytes())),
+		Request:       req,
+	}
+}
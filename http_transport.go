@@ -0,0 +1,109 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync/atomic"
+	"time"
+)
+
+// lastExchangeID is used to correlate dumped requests and responses
+var lastExchangeID uint64
+
+// Transport is an http.RoundTripper dumping the requests it sends and the
+// responses it receives:
+//
+//	client := &http.Client{
+//		Transport: &dumper.Transport{Base: http.DefaultTransport, Out: os.Stderr},
+//	}
+//
+// Bodies are left readable for the caller, and responses of unknown length,
+// like streamed ones, are not read.
+type Transport struct {
+	// Base is the RoundTripper sending the requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+	// Out is where requests and responses are dumped. If nil, os.Stderr
+	// is used.
+	Out io.Writer
+	// Options are used to dump requests and responses.
+	Options Options
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := atomic.AddUint64(&lastExchangeID, 1)
+
+	// Don't modify the caller's request: dumping replaces the body
+	req = req.Clone(req.Context())
+	t.dump(fmt.Sprintf("--> #%d %s %s", id, req.Method, req.URL), req)
+
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	elapsed := time.Since(start)
+	if err != nil {
+		t.dump(fmt.Sprintf("<-- #%d failed after %s", id, elapsed), err)
+		return nil, err
+	}
+
+	title := fmt.Sprintf("<-- #%d %s (%s)", id, resp.Status, elapsed)
+	if resp.ContentLength < 0 {
+		// the body of a response of unknown length, like an HTTP/2 or a
+		// close-delimited one, might never end, so it must not be read
+		shown := *resp
+		shown.Body = &streamedBody{resp.Body}
+		t.dump(title, &shown)
+	} else {
+		t.dump(title, resp)
+	}
+
+	return resp, nil
+}
+
+// streamedBody marks the bodies which must not be read when dumped
+type streamedBody struct {
+	io.ReadCloser
+}
+
+func (t *Transport) dump(title string, v interface{}) {
+	dumpExchange(t.Out, t.Options, title, v)
+}
+
+// dumpExchange writes the title and the dump of the value at once, so that
+// concurrent exchanges are not interleaved
+func dumpExchange(out io.Writer, opts Options, title string, v interface{}) {
+	if out == nil {
+		out = os.Stderr
+	}
+
+	buf := &bytes.Buffer{}
+	fmt.Fprintln(buf, title)
+	opts.Fdump(buf, v)
+	_, _ = out.Write(buf.Bytes())
+}
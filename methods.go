@@ -0,0 +1,169 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+// MethodFallback selects the methods used to display the values of types
+// without a custom dumper. The first enabled method implemented by the type
+// is used.
+type MethodFallback uint8
+
+const (
+	// FallbackStringer uses the String() method of fmt.Stringer
+	FallbackStringer MethodFallback = 1 << iota
+	// FallbackTextMarshaler uses the MarshalText() method of encoding.TextMarshaler
+	FallbackTextMarshaler
+	// FallbackGoStringer uses the GoString() method of fmt.GoStringer
+	FallbackGoStringer
+)
+
+var methodFallbacks = []struct {
+	flag   MethodFallback
+	iface  reflect.Type
+	method string
+	call   func(interface{}) (string, error)
+}{
+	{
+		flag:   FallbackStringer,
+		iface:  reflect.TypeOf((*fmt.Stringer)(nil)).Elem(),
+		method: "String",
+		call: func(v interface{}) (string, error) {
+			return v.(fmt.Stringer).String(), nil
+		},
+	},
+	{
+		flag:   FallbackTextMarshaler,
+		iface:  reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem(),
+		method: "MarshalText",
+		call: func(v interface{}) (string, error) {
+			text, err := v.(encoding.TextMarshaler).MarshalText()
+			return string(text), err
+		},
+	},
+	{
+		flag:   FallbackGoStringer,
+		iface:  reflect.TypeOf((*fmt.GoStringer)(nil)).Elem(),
+		method: "GoString",
+		call: func(v interface{}) (string, error) {
+			return v.(fmt.GoStringer).GoString(), nil
+		},
+	},
+}
+
+// methodDumper displays the result of a method as the primary value; struct
+// fields are still dumped after it.
+type methodDumper struct {
+	s        *state
+	v        reflect.Value
+	receiver reflect.Value
+	method   string
+	call     func(interface{}) (string, error)
+}
+
+// methodDumperFor returns a dumper for the first enabled method fallback
+// implemented by the value, if any. Pointers are skipped as the pointed value
+// is checked instead.
+func (s *state) methodDumperFor(v reflect.Value) Dumpable {
+	if s.opts.Fallbacks == 0 || !v.CanInterface() {
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return nil
+	}
+
+	typ := v.Type()
+	for _, f := range methodFallbacks {
+		if s.opts.Fallbacks&f.flag == 0 {
+			continue
+		}
+
+		receiver := v
+		if !typ.Implements(f.iface) {
+			if !reflect.PtrTo(typ).Implements(f.iface) {
+				continue
+			}
+			if v.CanAddr() {
+				receiver = v.Addr()
+			} else {
+				receiver = addressable(v)
+			}
+		}
+
+		return &methodDumper{s: s, v: v, receiver: receiver, method: f.method, call: f.call}
+	}
+
+	return nil
+}
+
+// result calls the method, recovering from panics like the ones happening
+// when the method does not support nil receivers or zero values.
+func (d *methodDumper) result() (str string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s() panicked: %v", d.method, r)
+		}
+	}()
+
+	return d.call(d.receiver.Interface())
+}
+
+func (d *methodDumper) Dump(State) {
+	s := d.s
+	name := d.method + "()"
+	result, err := d.result()
+
+	if d.v.Kind() == reflect.Struct {
+		if err != nil {
+			s.AddComment(err.Error())
+			s.DumpStructField(name, reflect.Value{})
+		} else {
+			s.DumpStructField(name, reflect.ValueOf(result))
+		}
+		s.DumpStructFields(d.v, nil)
+		return
+	}
+
+	if err != nil {
+		s.AddComment(fmt.Sprintf("%s, %s", d.v.Type(), err))
+		s.dumpDefault(d.v)
+		return
+	}
+
+	comment := fmt.Sprintf("%s.%s", d.v.Type(), name)
+	switch d.v.Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		comment = fmt.Sprintf("%s, %s", comment, s.WithTempBuffer(func(buf *bytes.Buffer) {
+			s.dumpDefault(d.v)
+		}))
+	}
+	s.AddComment(comment)
+	s.DumpString(result)
+}
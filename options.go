@@ -0,0 +1,173 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// DefaultMaxBytes is the default maximum number of bytes dumped for byte
+// slices and arrays, and for HTTP bodies.
+const DefaultMaxBytes = 4096
+
+// Options configures how values are dumped. The zero value is ready to use.
+type Options struct {
+	// MaxBytes is the maximum number of bytes dumped for byte slices and
+	// arrays, and for HTTP bodies. If zero, DefaultMaxBytes is used; a
+	// negative value disables the limit.
+	MaxBytes int
+
+	// Fallbacks enables displaying the result of some well-known methods
+	// for the types without a custom dumper.
+	Fallbacks MethodFallback
+
+	// Redact lists the names of the HTTP headers, struct fields, and map
+	// keys whose values are hidden, compared case-insensitively.
+	// SensitiveHeaders is a good start.
+	Redact []string
+
+	// MaxDepth is the maximum number of nested structs, maps, arrays, and
+	// slices dumped; deeper ones are elided. Zero means no limit.
+	MaxDepth int
+
+	// MaxItems is the maximum number of elements dumped for maps, arrays,
+	// and slices. Zero means no limit.
+	MaxItems int
+	// Multiline dumps each element of maps, arrays, and slices on its own
+	// line.
+	Multiline bool
+
+	// ExcludeTypes hides the values having the same type as one of the
+	// given ones, like (*zap.Logger)(nil) or sync.Mutex{}.
+	ExcludeTypes []interface{}
+
+	// ExcludePaths hides the values whose path matches one of the globs,
+	// like "**.cache" or ".Users[*].Password"; paths are the ones used by
+	// Select, "*" matching a single step and "**" any number of them.
+	ExcludePaths []string
+
+	// Exclude hides the values for which it returns true.
+	Exclude func(path string, v reflect.Value) bool
+
+	// OmitZero hides the struct fields having a zero value, according to
+	// their IsZero() method when they have one.
+	OmitZero bool
+
+	// Now returns the current time, used to describe times relatively,
+	// like "3m ago", and to timestamp remote dumps; set it to get
+	// reproducible dumps. If nil, time.Now is used.
+	Now func() time.Time
+
+	// Select restricts the dump to the parts of the values matching the
+	// query, see Select.
+	Select string
+
+	// singleLine dumps the values on a single line
+	singleLine bool
+
+	// noComments omits the comments
+	noComments bool
+
+	// caller is the package the values are dumped from, whose unexported
+	// fields are displayed; it is guessed from the stack when empty
+	caller string
+}
+
+// SensitiveHeaders lists the HTTP headers usually holding credentials.
+var SensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie"}
+
+// IsRedacted checks whether the values of the HTTP headers, struct fields,
+// or map keys with the given name are hidden.
+func (o Options) IsRedacted(name string) bool {
+	for _, redacted := range o.Redact {
+		if strings.EqualFold(redacted, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DefaultOptions are the options used by the package-level functions.
+var DefaultOptions = Options{}
+
+// optionsOf returns the options used by the state given to custom dumpers
+func optionsOf(s State) Options {
+	if ss, ok := s.(*state); ok {
+		return ss.opts
+	}
+
+	return DefaultOptions
+}
+
+// isTooDeep checks whether containers dumped at the given depth are elided
+func (o Options) isTooDeep(depth int) bool {
+	return o.MaxDepth > 0 && depth >= o.MaxDepth
+}
+
+// maxItems returns the number of elements dumped out of n
+func (o Options) maxItems(n int) int {
+	if o.MaxItems > 0 && n > o.MaxItems {
+		return o.MaxItems
+	}
+
+	return n
+}
+
+func (o Options) now() time.Time {
+	if o.Now == nil {
+		return time.Now()
+	}
+
+	return o.Now()
+}
+
+func (o Options) maxBytes() int {
+	if o.MaxBytes == 0 {
+		return DefaultMaxBytes
+	}
+
+	return o.MaxBytes
+}
+
+// Fdump prints to the writer the value with indentation.
+func (o Options) Fdump(out io.Writer, values ...interface{}) {
+	fdump(out, o, defaultStyles, values...)
+	_, _ = out.Write([]byte("\n"))
+}
+
+// Sdump dumps the values into a string with indentation.
+func (o Options) Sdump(values ...interface{}) string {
+	buf := &bytes.Buffer{}
+
+	fdump(buf, o, defaultStyles, values...)
+
+	return buf.String()
+}
+
+// FdumpColor prints to the writer the value with indentation and color.
+func (o Options) FdumpColor(out io.Writer, values ...interface{}) {
+	fdump(out, o, colorStyles, values...)
+	_, _ = out.Write([]byte("\n"))
+}
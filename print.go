@@ -23,8 +23,12 @@ import (
 	"bytes"
 	"fmt"
 	"io"
+	"math"
 	"path/filepath"
 	"reflect"
+	"sort"
+	"strconv"
+	"strings"
 )
 
 const ElementsPerLine = 30
@@ -80,6 +84,11 @@ func (s *state) ForceNewLines(v bool) bool {
 }
 
 func (s *state) DumpString(str string) {
+	if s.opts.singleLine {
+		// line breaks would be joined with the surrounding lines
+		str = strconv.Quote(str)
+		str = str[1 : len(str)-1]
+	}
 	s.printfStyle("const", "\"")
 	s.printfStyle("str", "%v", str)
 	s.printfStyle("const", "\"")
@@ -134,7 +143,10 @@ func (s *state) DumpStructComments(v reflect.Value) string {
 		case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
 			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
 			reflect.Float32:
-			s.printfStyle("meta", "%v", v.Type().Name())
+			// custom dumpers add their own comments
+			if s.customDumperFor(v) == nil {
+				s.printfStyle("meta", "%v", v.Type().Name())
+			}
 
 		case reflect.Ptr:
 			if v.IsNil() {
@@ -165,7 +177,14 @@ func DumpStructWithPrivateFields(s State, v reflect.Value) {
 }
 
 func (s *state) DumpStructFields(value reflect.Value, hidePrivateFields *bool) {
+	s.dumpStructFields(value, hidePrivateFields, nil)
+}
+
+// dumpStructFields dumps the fields of the struct, except the ones for which
+// skip returns true
+func (s *state) dumpStructFields(value reflect.Value, hidePrivateFields *bool, skip func(reflect.Value) bool) {
 	typ := value.Type()
+	zeroFields := 0
 
 	for i, numFields := 0, value.NumField(); i < numFields; i++ {
 		field := typ.Field(i)
@@ -180,19 +199,104 @@ func (s *state) DumpStructFields(value reflect.Value, hidePrivateFields *bool) {
 				continue
 			}
 		}
-		s.DumpStructField(field.Name, value.Field(i))
+		if field.PkgPath != "" && !value.CanAddr() && value.CanInterface() {
+			// unexported fields are only exposed through their address
+			value = addressable(value).Elem()
+		}
+		f := exposed(value.Field(i))
+		if skip != nil && skip(f) {
+			continue
+		}
+		if s.opts.OmitZero && isZero(f) {
+			zeroFields++
+			continue
+		}
+		if s.opts.IsRedacted(field.Name) {
+			s.DumpStructField(field.Name, reflect.ValueOf(redactedValue))
+			continue
+		}
+		s.DumpStructField(field.Name, f)
+	}
+
+	if zeroFields > 0 && !s.noComments {
+		s.Pad()
+		if zeroFields == 1 {
+			s.printf("// 1 zero field hidden\n")
+		} else {
+			s.printf("// %d zero fields hidden\n", zeroFields)
+		}
+	}
+}
+
+type zeroer interface {
+	IsZero() bool
+}
+
+// isZero checks whether the value is zero, using its IsZero() method when
+// it has one, like time.Time
+func isZero(v reflect.Value) bool {
+	if v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return true
+		}
+	}
+
+	if v.CanInterface() {
+		if z, ok := v.Interface().(zeroer); ok {
+			if zero, ok := callIsZero(z); ok {
+				return zero
+			}
+		} else if v.CanAddr() {
+			if z, ok := v.Addr().Interface().(zeroer); ok {
+				if zero, ok := callIsZero(z); ok {
+					return zero
+				}
+			}
+		}
 	}
+
+	return v.IsZero()
+}
+
+// callIsZero calls the IsZero() method, recovering from panics like the ones
+// happening when the method does not support zero values; ok is false then.
+func callIsZero(z zeroer) (zero bool, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			zero, ok = false, false
+		}
+	}()
+
+	return z.IsZero(), true
 }
 
 func (s *state) DumpStructField(fieldName string, v reflect.Value) {
 	s.Pad()
 	s.printf("%v: ", fieldName)
-	s.dumpVal(v)
+	s.dumpValAt(fieldStep(fieldName), v)
 	s.printf(",%s\n", s.DumpStructComments(v))
 }
 
+// sortedMapEntries returns the keys and values of the map, sorted by key.
+// Entries are read by iterating over the map as NaN keys cannot be looked up.
+func sortedMapEntries(v reflect.Value) ([]reflect.Value, []reflect.Value) {
+	sorter := mapKeysSorter{
+		keys:   make([]reflect.Value, 0, v.Len()),
+		values: make([]reflect.Value, 0, v.Len()),
+	}
+	iter := v.MapRange()
+	for iter.Next() {
+		sorter.keys = append(sorter.keys, iter.Key())
+		sorter.values = append(sorter.values, iter.Value())
+	}
+	sort.Stable(sorter)
+
+	return sorter.keys, sorter.values
+}
+
 type mapKeysSorter struct {
-	keys []reflect.Value
+	keys   []reflect.Value
+	values []reflect.Value
 }
 
 func (s mapKeysSorter) Len() int {
@@ -201,8 +305,95 @@ func (s mapKeysSorter) Len() int {
 
 func (s mapKeysSorter) Swap(i, j int) {
 	s.keys[i], s.keys[j] = s.keys[j], s.keys[i]
+	s.values[i], s.values[j] = s.values[j], s.values[i]
 }
 
 func (s mapKeysSorter) Less(i, j int) bool {
-	return s.keys[i].String() < s.keys[j].String()
+	return compareValues(s.keys[i], s.keys[j]) < 0
+}
+
+// compareValues returns -1, 0 or 1 depending on how a compares to b. Both
+// values must have the same type. The ordering is the one used by the fmt
+// package when printing maps: numbers, strings and booleans are compared by
+// value, pointers by address, structs and arrays element by element, and
+// interfaces first by type then by value.
+func compareValues(a, b reflect.Value) int {
+	switch a.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return compareOrdered(a.Int() < b.Int(), a.Int() > b.Int())
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return compareOrdered(a.Uint() < b.Uint(), a.Uint() > b.Uint())
+
+	case reflect.String:
+		return strings.Compare(a.String(), b.String())
+
+	case reflect.Float32, reflect.Float64:
+		return compareFloats(a.Float(), b.Float())
+
+	case reflect.Complex64, reflect.Complex128:
+		if c := compareFloats(real(a.Complex()), real(b.Complex())); c != 0 {
+			return c
+		}
+		return compareFloats(imag(a.Complex()), imag(b.Complex()))
+
+	case reflect.Bool:
+		return compareOrdered(!a.Bool() && b.Bool(), a.Bool() && !b.Bool())
+
+	case reflect.Ptr, reflect.UnsafePointer, reflect.Chan:
+		return compareOrdered(a.Pointer() < b.Pointer(), a.Pointer() > b.Pointer())
+
+	case reflect.Struct:
+		for i := 0; i < a.NumField(); i++ {
+			if c := compareValues(a.Field(i), b.Field(i)); c != 0 {
+				return c
+			}
+		}
+		return 0
+
+	case reflect.Array:
+		for i := 0; i < a.Len(); i++ {
+			if c := compareValues(a.Index(i), b.Index(i)); c != 0 {
+				return c
+			}
+		}
+		return 0
+
+	case reflect.Interface:
+		// nil interfaces come first
+		if a.IsNil() || b.IsNil() {
+			return compareOrdered(a.IsNil() && !b.IsNil(), !a.IsNil() && b.IsNil())
+		}
+		ta, tb := a.Elem().Type(), b.Elem().Type()
+		if c := strings.Compare(ta.String(), tb.String()); c != 0 {
+			return c
+		}
+		if ta != tb {
+			return compareOrdered(ta.Kind() < tb.Kind(), ta.Kind() > tb.Kind())
+		}
+		return compareValues(a.Elem(), b.Elem())
+	}
+
+	return 0
+}
+
+func compareOrdered(less, greater bool) int {
+	switch {
+	case less:
+		return -1
+	case greater:
+		return 1
+	}
+
+	return 0
+}
+
+// compareFloats orders floats, NaN values coming first.
+func compareFloats(a, b float64) int {
+	aNaN, bNaN := math.IsNaN(a), math.IsNaN(b)
+	if aNaN || bNaN {
+		return compareOrdered(aNaN && !bNaN, !aNaN && bNaN)
+	}
+
+	return compareOrdered(a < b, a > b)
 }
@@ -0,0 +1,350 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultServerAddr is the address used when none is configured, the one of
+// Symfony's server:dump command.
+const DefaultServerAddr = "127.0.0.1:9912"
+
+// Message is a dump sent to a Server, by a RemoteWriter or by Symfony's
+// VarDumper. Messages are exchanged with the protocol of VarDumper, so that
+// dumps can be sent to Symfony's server:dump command, and the dumps of PHP
+// applications can be received by a Server.
+type Message struct {
+	Dump     string
+	File     string
+	Line     int
+	Time     time.Time
+	Hostname string
+	PID      int
+}
+
+// RemoteWriter sends dumps to a Server. When no server is listening, dumps
+// are written to the Fallback writer instead.
+//
+// Use it as the default dumper with:
+//
+//	dumper.Dump = (&dumper.RemoteWriter{}).Dump
+type RemoteWriter struct {
+	// Addr is the address of the server, DefaultServerAddr if empty.
+	Addr string
+	// Fallback receives the dumps when the server cannot be reached,
+	// os.Stdout if nil.
+	Fallback io.Writer
+	// Options configures how values are dumped.
+	Options Options
+	// Timeout limits the time spent connecting and sending a dump, one
+	// second if zero.
+	Timeout time.Duration
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Dump sends the values to the server, or writes them to the fallback
+// writer.
+func (w *RemoteWriter) Dump(values ...interface{}) {
+	file, line := callerLocation(1)
+
+	buf := &bytes.Buffer{}
+	fdump(buf, w.Options, colorStyles, values...)
+	if w.send(buf.String(), file, line) == nil {
+		return
+	}
+
+	w.Options.Fdump(w.fallback(), values...)
+}
+
+// Write sends p as a whole dump to the server, or writes it to the fallback
+// writer.
+func (w *RemoteWriter) Write(p []byte) (int, error) {
+	file, line := callerLocation(1)
+	if w.send(string(p), file, line) == nil {
+		return len(p), nil
+	}
+
+	return w.fallback().Write(p)
+}
+
+// Close closes the connection to the server, if any.
+func (w *RemoteWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+
+	return errors.WithStack(err)
+}
+
+func (w *RemoteWriter) fallback() io.Writer {
+	if w.Fallback == nil {
+		return os.Stdout
+	}
+
+	return w.Fallback
+}
+
+func (w *RemoteWriter) timeout() time.Duration {
+	if w.Timeout == 0 {
+		return time.Second
+	}
+
+	return w.Timeout
+}
+
+func (w *RemoteWriter) send(dump, file string, line int) error {
+	hostname, _ := os.Hostname()
+	data := encodePayload(Message{
+		Dump:     dump,
+		File:     file,
+		Line:     line,
+		Time:     w.Options.now(),
+		Hostname: hostname,
+		PID:      os.Getpid(),
+	})
+
+	var err error
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn != nil && !isAlive(w.conn) {
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	// writing to a connection broken since the previous dump might still
+	// fail, so sending is tried twice
+	for attempt := 0; attempt < 2; attempt++ {
+		if w.conn == nil {
+			addr := w.Addr
+			if addr == "" {
+				addr = DefaultServerAddr
+			}
+			if w.conn, err = net.DialTimeout("tcp", addr, w.timeout()); err != nil {
+				w.conn = nil
+				return errors.WithStack(err)
+			}
+		}
+
+		_ = w.conn.SetWriteDeadline(time.Now().Add(w.timeout()))
+		if _, err = w.conn.Write(data); err == nil {
+			return nil
+		}
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+
+	return errors.WithStack(err)
+}
+
+// isAlive checks whether the server closed the connection. As the server
+// never sends anything, reading briefly only times out when the connection
+// is still open. An expired deadline would fail before even reading.
+func isAlive(conn net.Conn) bool {
+	_ = conn.SetReadDeadline(time.Now().Add(time.Millisecond))
+	_, err := conn.Read(make([]byte, 1))
+	_ = conn.SetReadDeadline(time.Time{})
+
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// callerLocation returns the file and line of the caller, skip being the
+// number of frames to ascend from the function calling it, as for
+// runtime.Caller. The wrappers generated for method values, like
+// dumper.Dump = w.Dump, are skipped.
+func callerLocation(skip int) (string, int) {
+	for {
+		_, file, line, ok := runtime.Caller(skip + 1)
+		if !ok {
+			return "", 0
+		}
+		if file != "<autogenerated>" {
+			return file, line
+		}
+		skip++
+	}
+}
+
+// Server receives the dumps sent by RemoteWriters, or by the PHP applications
+// using Symfony's VarDumper, and prints them.
+type Server struct {
+	// Addr is the address to listen on, DefaultServerAddr if empty.
+	Addr string
+	// Out receives the dumps, os.Stdout if nil.
+	Out io.Writer
+
+	mu       sync.Mutex
+	listener net.Listener
+	conns    map[net.Conn]struct{}
+	closed   bool
+}
+
+// ListenAndServe listens on the server address and handles the incoming
+// dumps.
+func (srv *Server) ListenAndServe() error {
+	addr := srv.Addr
+	if addr == "" {
+		addr = DefaultServerAddr
+	}
+
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+
+	return srv.Serve(l)
+}
+
+// Serve handles the dumps received on the listener until the server is
+// closed, in which case it returns nil.
+func (srv *Server) Serve(l net.Listener) error {
+	srv.mu.Lock()
+	if srv.closed {
+		srv.mu.Unlock()
+		_ = l.Close()
+		return nil
+	}
+	srv.listener = l
+	srv.mu.Unlock()
+
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			srv.mu.Lock()
+			closed := srv.closed
+			srv.mu.Unlock()
+			if closed {
+				return nil
+			}
+
+			return errors.WithStack(err)
+		}
+
+		srv.mu.Lock()
+		if srv.conns == nil {
+			srv.conns = map[net.Conn]struct{}{}
+		}
+		srv.conns[conn] = struct{}{}
+		srv.mu.Unlock()
+
+		go srv.handle(conn)
+	}
+}
+
+// Close stops listening and closes all the connections.
+func (srv *Server) Close() error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	srv.closed = true
+	for conn := range srv.conns {
+		_ = conn.Close()
+	}
+	srv.conns = nil
+
+	if srv.listener == nil {
+		return nil
+	}
+
+	return errors.WithStack(srv.listener.Close())
+}
+
+func (srv *Server) handle(conn net.Conn) {
+	defer func() {
+		srv.mu.Lock()
+		delete(srv.conns, conn)
+		srv.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		msg, err := decodePayload(scanner.Bytes())
+		if err != nil {
+			srv.printInvalid(conn, err)
+			continue
+		}
+		srv.print(msg)
+	}
+}
+
+// printInvalid reports the messages which cannot be decoded
+func (srv *Server) printInvalid(conn net.Conn, err error) {
+	buf := &bytes.Buffer{}
+	s := state{w: buf, styles: colorStyles}
+	s.printfStyle("ref", "ignored an invalid message from %s: %s", conn.RemoteAddr(), err)
+	s.printf("\n")
+
+	srv.write(buf.Bytes())
+}
+
+func (srv *Server) print(msg Message) {
+	buf := &bytes.Buffer{}
+	s := state{w: buf, styles: colorStyles}
+	s.printfStyle("note", "%s", msg.Time.Format("2006-01-02 15:04:05"))
+	// dumps sent by VarDumper do not tell where they come from
+	if msg.Hostname != "" {
+		s.printf(" ")
+		s.printfStyle("meta", "%s[%d]", msg.Hostname, msg.PID)
+	}
+	if msg.File != "" {
+		s.printf(" ")
+		s.printfStyle("ref", "%s:%d", filepath.Base(msg.File), msg.Line)
+	}
+	s.printf("\n%s\n", strings.TrimRight(msg.Dump, "\n"))
+
+	srv.write(buf.Bytes())
+}
+
+func (srv *Server) write(data []byte) {
+	out := srv.Out
+	if out == nil {
+		out = os.Stdout
+	}
+
+	// dumps from concurrent connections must not be interleaved
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	_, _ = out.Write(data)
+}
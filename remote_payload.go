@@ -0,0 +1,629 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Symfony's VarDumper sends each dump to its server as a line holding the
+// base64 encoding of the PHP serialization of [$data, $context], $data being
+// the Symfony\Component\VarDumper\Cloner\Data cloned from the variable.
+const varDumperData = `Symfony\Component\VarDumper\Cloner\Data`
+
+// types of the Symfony\Component\VarDumper\Cloner\Stub objects
+const (
+	stubRef      = 1
+	stubString   = 2
+	stubArray    = 3
+	stubObject   = 4
+	stubResource = 5
+)
+
+// maxPayloadDepth stops rendering payloads nested too deeply to come from
+// VarDumper
+const maxPayloadDepth = 100
+
+var ansiSequences = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// encodePayload encodes the message as VarDumper does. The Data holds the
+// dump as a string, displayed as such by Symfony's server, while the colored
+// dump, the hostname, and the pid are added to the context, which Symfony's
+// server ignores.
+func encodePayload(msg Message) []byte {
+	plain := strings.TrimRight(ansiSequences.ReplaceAllString(msg.Dump, ""), "\n")
+
+	data := &phpObject{class: varDumperData, props: &phpArray{}}
+	data.props.set("\x00"+varDumperData+"\x00data", phpList(phpList(plain)))
+	data.props.set("\x00"+varDumperData+"\x00position", 0)
+	data.props.set("\x00"+varDumperData+"\x00key", 0)
+
+	context := &phpArray{}
+	context.set("timestamp", float64(msg.Time.UnixNano()/int64(time.Microsecond))/1e6)
+	if msg.File != "" {
+		source := &phpArray{}
+		source.set("name", filepath.Base(msg.File))
+		source.set("file", msg.File)
+		source.set("line", msg.Line)
+		source.set("file_excerpt", false)
+		context.set("source", source)
+	}
+	cli := &phpArray{}
+	cli.set("command_line", strings.Join(os.Args, " "))
+	cli.set("identifier", fmt.Sprintf("%s:%d", msg.Hostname, msg.PID))
+	context.set("cli", cli)
+	context.set("hostname", msg.Hostname)
+	context.set("pid", msg.PID)
+	context.set("ansi", msg.Dump)
+
+	buf := &bytes.Buffer{}
+	serializePHP(buf, phpList(data, context))
+
+	return append([]byte(base64.StdEncoding.EncodeToString(buf.Bytes())), '\n')
+}
+
+// decodePayload decodes a line sent by a RemoteWriter or by VarDumper
+func decodePayload(line []byte) (Message, error) {
+	raw, err := base64.StdEncoding.DecodeString(string(line))
+	if err != nil {
+		return Message{}, errors.Wrap(err, "invalid base64")
+	}
+
+	v, err := unserializePHP(raw)
+	if err != nil {
+		return Message{}, err
+	}
+	payload, _ := v.(*phpArray)
+	if payload == nil || len(payload.values) != 2 {
+		return Message{}, errors.New("not a VarDumper payload")
+	}
+	data, _ := payload.values[0].(*phpObject)
+	context, _ := payload.values[1].(*phpArray)
+	if data == nil || data.class != varDumperData || context == nil {
+		return Message{}, errors.New("not a VarDumper payload")
+	}
+
+	msg := Message{}
+	switch ts := context.get("timestamp").(type) {
+	case float64:
+		msg.Time = time.Unix(0, int64(math.Round(ts*1e6))*int64(time.Microsecond))
+	case int64:
+		msg.Time = time.Unix(ts, 0)
+	}
+	if source, ok := context.get("source").(*phpArray); ok {
+		msg.File, _ = source.get("file").(string)
+		line, _ := source.get("line").(int64)
+		msg.Line = int(line)
+	}
+	msg.Hostname, _ = context.get("hostname").(string)
+	pid, _ := context.get("pid").(int64)
+	msg.PID = int(pid)
+
+	if ansi, ok := context.get("ansi").(string); ok {
+		msg.Dump = ansi
+	} else {
+		msg.Dump = renderPHPData(data)
+	}
+
+	return msg, nil
+}
+
+// phpArray is an ordered PHP array, with int64 or string keys
+type phpArray struct {
+	keys   []interface{}
+	values []interface{}
+}
+
+func phpList(values ...interface{}) *phpArray {
+	a := &phpArray{}
+	for i, v := range values {
+		a.set(int64(i), v)
+	}
+
+	return a
+}
+
+func (a *phpArray) set(key, value interface{}) {
+	a.keys = append(a.keys, key)
+	a.values = append(a.values, value)
+}
+
+func (a *phpArray) get(key interface{}) interface{} {
+	if i, ok := key.(int); ok {
+		key = int64(i)
+	}
+	for i, k := range a.keys {
+		if k == key {
+			return a.values[i]
+		}
+	}
+
+	return nil
+}
+
+// phpObject is a PHP object, the names of its private and protected
+// properties being prefixed as done by serialize()
+type phpObject struct {
+	class string
+	props *phpArray
+}
+
+// prop returns the property, whatever its visibility
+func (o *phpObject) prop(name string) interface{} {
+	for i, k := range o.props.keys {
+		if k, ok := k.(string); ok && (k == name || strings.HasSuffix(k, "\x00"+name)) {
+			return o.props.values[i]
+		}
+	}
+
+	return nil
+}
+
+func serializePHP(buf *bytes.Buffer, v interface{}) {
+	switch v := v.(type) {
+	case nil:
+		buf.WriteString("N;")
+	case bool:
+		if v {
+			buf.WriteString("b:1;")
+		} else {
+			buf.WriteString("b:0;")
+		}
+	case int:
+		fmt.Fprintf(buf, "i:%d;", v)
+	case int64:
+		fmt.Fprintf(buf, "i:%d;", v)
+	case float64:
+		switch {
+		case math.IsNaN(v):
+			buf.WriteString("d:NAN;")
+		case math.IsInf(v, 1):
+			buf.WriteString("d:INF;")
+		case math.IsInf(v, -1):
+			buf.WriteString("d:-INF;")
+		default:
+			fmt.Fprintf(buf, "d:%s;", strconv.FormatFloat(v, 'f', -1, 64))
+		}
+	case string:
+		fmt.Fprintf(buf, "s:%d:\"%s\";", len(v), v)
+	case *phpArray:
+		fmt.Fprintf(buf, "a:%d:{", len(v.keys))
+		serializePHPEntries(buf, v)
+		buf.WriteString("}")
+	case *phpObject:
+		fmt.Fprintf(buf, "O:%d:\"%s\":%d:{", len(v.class), v.class, len(v.props.keys))
+		serializePHPEntries(buf, v.props)
+		buf.WriteString("}")
+	default:
+		panic(fmt.Sprintf("cannot serialize %T", v))
+	}
+}
+
+func serializePHPEntries(buf *bytes.Buffer, a *phpArray) {
+	for i, k := range a.keys {
+		serializePHP(buf, k)
+		serializePHP(buf, a.values[i])
+	}
+}
+
+// phpUnserializer decodes the output of PHP's serialize(). Values are
+// numbered in the order they are read, as back references use these
+// numbers, starting at 1.
+type phpUnserializer struct {
+	data  []byte
+	pos   int
+	slots []interface{}
+}
+
+func unserializePHP(data []byte) (interface{}, error) {
+	u := &phpUnserializer{data: data}
+	v, err := u.value(true)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid PHP serialization at offset %d", u.pos)
+	}
+	if u.pos != len(data) {
+		return nil, errors.Errorf("unexpected data after the PHP serialization at offset %d", u.pos)
+	}
+
+	return v, nil
+}
+
+// value reads the next value; keys of arrays and names of properties are
+// not numbered
+func (u *phpUnserializer) value(numbered bool) (interface{}, error) {
+	if u.pos+1 >= len(u.data) {
+		return nil, errors.New("unexpected end")
+	}
+
+	typ := u.data[u.pos]
+	slot := -1
+	if numbered && typ != 'R' {
+		slot = len(u.slots)
+		u.slots = append(u.slots, nil)
+	}
+
+	if typ == 'N' {
+		u.pos++
+		return nil, u.expect(";")
+	}
+	if err := u.expect(string(typ) + ":"); err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case 'b':
+		n, err := u.int(';')
+		return n != 0, err
+
+	case 'i':
+		return u.int(';')
+
+	case 'd':
+		end := bytes.IndexByte(u.data[u.pos:], ';')
+		if end < 0 {
+			return nil, errors.New("unterminated float")
+		}
+		str := string(u.data[u.pos : u.pos+end])
+		u.pos += end + 1
+		switch str {
+		case "INF":
+			return math.Inf(1), nil
+		case "-INF":
+			return math.Inf(-1), nil
+		case "NAN":
+			return math.NaN(), nil
+		}
+		f, err := strconv.ParseFloat(str, 64)
+		return f, errors.WithStack(err)
+
+	case 's':
+		str, err := u.string()
+		if err != nil {
+			return nil, err
+		}
+		return str, u.expect(";")
+
+	case 'a':
+		a := &phpArray{}
+		if slot >= 0 {
+			u.slots[slot] = a
+		}
+		return a, u.entries(a, true)
+
+	case 'O', 'C':
+		class, err := u.string()
+		if err != nil {
+			return nil, err
+		}
+		if err := u.expect(":"); err != nil {
+			return nil, err
+		}
+		o := &phpObject{class: class, props: &phpArray{}}
+		if slot >= 0 {
+			u.slots[slot] = o
+		}
+		if typ == 'O' {
+			return o, u.entries(o.props, false)
+		}
+		// the content of objects serialized by Serializable is opaque
+		_, err = u.bytes()
+		return o, err
+
+	case 'r', 'R':
+		n, err := u.int(';')
+		if err != nil {
+			return nil, err
+		}
+		if n < 1 || n > int64(len(u.slots)) {
+			return nil, errors.Errorf("invalid back reference %d", n)
+		}
+		v := u.slots[n-1]
+		if slot >= 0 {
+			u.slots[slot] = v
+		}
+		return v, nil
+	}
+
+	return nil, errors.Errorf("unsupported type %q", typ)
+}
+
+// entries reads the count and the entries of an array or of the properties
+// of an object
+func (u *phpUnserializer) entries(a *phpArray, intKeys bool) error {
+	n, err := u.int(':')
+	if err != nil {
+		return err
+	}
+	if n < 0 || n > int64(len(u.data)-u.pos) {
+		return errors.Errorf("invalid count %d", n)
+	}
+	if err := u.expect("{"); err != nil {
+		return err
+	}
+	for i := int64(0); i < n; i++ {
+		k, err := u.value(false)
+		if err != nil {
+			return err
+		}
+		switch k.(type) {
+		case string:
+		case int64:
+			if !intKeys {
+				return errors.New("invalid property name")
+			}
+		default:
+			return errors.New("invalid key")
+		}
+		v, err := u.value(true)
+		if err != nil {
+			return err
+		}
+		a.set(k, v)
+	}
+
+	return u.expect("}")
+}
+
+func (u *phpUnserializer) expect(s string) error {
+	if !bytes.HasPrefix(u.data[u.pos:], []byte(s)) {
+		return errors.Errorf("expected %q", s)
+	}
+	u.pos += len(s)
+
+	return nil
+}
+
+func (u *phpUnserializer) int(end byte) (int64, error) {
+	i := bytes.IndexByte(u.data[u.pos:], end)
+	if i < 0 {
+		return 0, errors.Errorf("expected %q", end)
+	}
+	n, err := strconv.ParseInt(string(u.data[u.pos:u.pos+i]), 10, 64)
+	if err != nil {
+		return 0, errors.WithStack(err)
+	}
+	u.pos += i + 1
+
+	return n, nil
+}
+
+// string reads a length-prefixed quoted string, as used for strings and
+// class names
+func (u *phpUnserializer) string() (string, error) {
+	n, err := u.int(':')
+	if err != nil {
+		return "", err
+	}
+	if n < 0 || n > int64(len(u.data)-u.pos-2) {
+		return "", errors.Errorf("invalid length %d", n)
+	}
+	if err := u.expect(`"`); err != nil {
+		return "", err
+	}
+	str := string(u.data[u.pos : u.pos+int(n)])
+	u.pos += int(n)
+
+	return str, u.expect(`"`)
+}
+
+// bytes reads a length-prefixed content between braces
+func (u *phpUnserializer) bytes() ([]byte, error) {
+	n, err := u.int(':')
+	if err != nil {
+		return nil, err
+	}
+	if n < 0 || n > int64(len(u.data)-u.pos-2) {
+		return nil, errors.Errorf("invalid length %d", n)
+	}
+	if err := u.expect("{"); err != nil {
+		return nil, err
+	}
+	b := u.data[u.pos : u.pos+int(n)]
+	u.pos += int(n)
+
+	return b, u.expect("}")
+}
+
+// phpDataRenderer renders a Data cloned by VarDumper, the way its CliDumper
+// does. The cloned variable is a table of rows, arrays, objects and
+// resources referencing the row holding their children by position.
+type phpDataRenderer struct {
+	s     *state
+	table *phpArray
+}
+
+func renderPHPData(data *phpObject) string {
+	buf := &bytes.Buffer{}
+	table, _ := data.prop("data").(*phpArray)
+	r := &phpDataRenderer{s: &state{w: buf, styles: colorStyles}, table: table}
+
+	if row := r.row(data.prop("position")); row != nil && len(row.keys) > 0 {
+		key := data.prop("key")
+		if key == nil {
+			key = int64(0)
+		}
+		r.item(row.get(key))
+	} else {
+		r.s.printfStyle("ref", "<invalid>")
+	}
+
+	return buf.String()
+}
+
+func (r *phpDataRenderer) row(position interface{}) *phpArray {
+	if r.table == nil || position == nil {
+		return nil
+	}
+	row, _ := r.table.get(position).(*phpArray)
+
+	return row
+}
+
+func (r *phpDataRenderer) item(v interface{}) {
+	s := r.s
+	switch v := v.(type) {
+	case nil:
+		s.printfStyle("const", "null")
+	case bool:
+		s.printfStyle("const", "%t", v)
+	case int64, float64:
+		s.printfStyle("num", "%v", v)
+	case string:
+		s.DumpString(v)
+	case *phpArray:
+		// arrays are compacted by VarCloner as [cut, class => position]
+		if len(v.keys) == 0 {
+			s.print("[]")
+			return
+		}
+		cut, _ := v.get(int64(0)).(int64)
+		if len(v.keys) == 1 {
+			cut = 0
+		}
+		children := r.row(v.values[len(v.values)-1])
+		r.array(children, cut)
+	case *phpObject:
+		r.stub(v)
+	default:
+		s.printfStyle("ref", "<unknown>")
+	}
+}
+
+func (r *phpDataRenderer) stub(stub *phpObject) {
+	s := r.s
+	typ, _ := stub.prop("type").(int64)
+	cut, _ := stub.prop("cut").(int64)
+	handle, _ := stub.prop("handle").(int64)
+	class := fmt.Sprint(stub.prop("class"))
+	children := r.row(stub.prop("position"))
+
+	switch typ {
+	case stubRef:
+		r.item(stub.prop("value"))
+	case stubString:
+		str, _ := stub.prop("value").(string)
+		s.DumpString(str)
+		if cut > 0 {
+			s.printfStyle("ref", "…%d", cut)
+		}
+	case stubArray:
+		r.array(children, cut)
+	case stubObject:
+		s.printfStyle("note", "%s", class)
+		s.print(" {")
+		s.printfStyle("ref", "#%d", handle)
+		r.children(children, cut, "}", true)
+	case stubResource:
+		s.printfStyle("note", "%s resource", class)
+		s.print(" {")
+		s.printfStyle("ref", "@%d", handle)
+		r.children(children, cut, "}", true)
+	default:
+		r.item(stub.prop("value"))
+	}
+}
+
+func (r *phpDataRenderer) array(children *phpArray, cut int64) {
+	count := cut
+	if children != nil {
+		count += int64(len(children.keys))
+	}
+	if count == 0 {
+		r.s.print("[]")
+		return
+	}
+	r.s.printfStyle("note", "array:%d", count)
+	r.s.print(" [")
+	r.children(children, cut, "]", false)
+}
+
+func (r *phpDataRenderer) children(children *phpArray, cut int64, closing string, isObject bool) {
+	s := r.s
+	if (children == nil || len(children.keys) == 0) && cut == 0 {
+		s.print(closing)
+		return
+	}
+	if s.depth >= maxPayloadDepth {
+		s.printfStyle("ref", "…")
+		s.print(closing)
+		return
+	}
+
+	s.print("\n")
+	s.DepthDown()
+	if children != nil {
+		for i, k := range children.keys {
+			s.Pad()
+			if isObject {
+				r.property(fmt.Sprint(k))
+			} else if key, ok := k.(string); ok {
+				s.printfStyle("key", "%q", key)
+				s.print(" => ")
+			} else {
+				s.printfStyle("index", "%v", k)
+				s.print(" => ")
+			}
+			r.item(children.values[i])
+			s.print("\n")
+		}
+	}
+	if cut > 0 {
+		s.Pad()
+		s.printfStyle("ref", "…%d", cut)
+		s.print("\n")
+	}
+	s.DepthUp()
+	s.Pad()
+	s.print(closing)
+}
+
+// property prints the name of a property with its visibility, given by the
+// prefix of its name: "\0*\0" for protected ones, "\0Class\0" for private
+// ones, and "\0~\0" for the virtual ones added by casters
+func (r *phpDataRenderer) property(name string) {
+	style, prefix := "public", "+"
+	if strings.HasPrefix(name, "\x00") {
+		if parts := strings.SplitN(name[1:], "\x00", 2); len(parts) == 2 {
+			name = parts[1]
+			switch parts[0] {
+			case "*":
+				style, prefix = "protected", "#"
+			case "~":
+				style, prefix = "meta", ""
+			case "+":
+			default:
+				style, prefix = "private", "-"
+			}
+		}
+	}
+	r.s.printfStyle(style, "%s%s", prefix, name)
+	r.s.print(": ")
+}
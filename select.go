@@ -0,0 +1,289 @@
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Selection holds the parts of a value matching a query, as returned by
+// Select.
+type Selection struct {
+	Query   string
+	Matches []Match
+	Err     error
+}
+
+// Match is a part of a value matching a query, with its full path.
+type Match struct {
+	Path  string
+	Value reflect.Value
+}
+
+// Select walks the value and returns its parts matching the query. The
+// query is a path made of field names and map keys (".Database", ".*"),
+// indices and quoted map keys ("[0]", `["api key"]`, "[*]"), where "*" is a
+// wildcard. Values with a custom dumper are matched as a whole, but never
+// walked through:
+//
+//	dumper.Dump(dumper.Select(cfg, ".Database.Replicas[*].Host"))
+func Select(v interface{}, query string) Selection {
+	return selectPath(reflect.ValueOf(v), query, DefaultOptions, lastCaller())
+}
+
+// selector is a step of a query
+type selector struct {
+	name     string
+	index    int
+	isIndex  bool
+	wildcard bool
+}
+
+func selectPath(v reflect.Value, query string, opts Options, caller string) Selection {
+	selection := Selection{Query: query}
+
+	selectors, err := parseQuery(query)
+	if err != nil {
+		selection.Err = err
+		return selection
+	}
+
+	w := walker{opts: opts, caller: caller}
+	w.walk(v, "", selectors)
+	selection.Matches = w.matches
+
+	return selection
+}
+
+func parseQuery(query string) ([]selector, error) {
+	var selectors []selector
+
+	for rest := query; rest != ""; {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			name := rest[1:end]
+			rest = rest[end:]
+			if name == "" {
+				if rest == "" && len(selectors) == 0 {
+					// "." is the whole value
+					continue
+				}
+				return nil, fmt.Errorf("empty name in %q", query)
+			}
+			selectors = append(selectors, selector{name: name, wildcard: name == "*"})
+
+		case '[':
+			end := strings.Index(rest, "]")
+			if strings.HasPrefix(rest, `["`) {
+				end = quotedEnd(rest)
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated %q in %q", rest, query)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+
+			if inner == "*" {
+				selectors = append(selectors, selector{wildcard: true})
+			} else if strings.HasPrefix(inner, `"`) {
+				name, err := strconv.Unquote(inner)
+				if err != nil {
+					return nil, fmt.Errorf("invalid key %s in %q", inner, query)
+				}
+				selectors = append(selectors, selector{name: name})
+			} else if i, err := strconv.Atoi(inner); err == nil {
+				selectors = append(selectors, selector{name: inner, index: i, isIndex: true})
+			} else {
+				return nil, fmt.Errorf("invalid index %q in %q", inner, query)
+			}
+
+		default:
+			return nil, fmt.Errorf("unexpected %q in %q, expected \".\" or \"[\"", rest[0], query)
+		}
+	}
+
+	return selectors, nil
+}
+
+// quotedEnd returns the position of the bracket closing a quoted key
+func quotedEnd(s string) int {
+	for i := 2; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			if i+1 < len(s) && s[i+1] == ']' {
+				return i + 1
+			}
+			return -1
+		}
+	}
+
+	return -1
+}
+
+type walker struct {
+	opts    Options
+	caller  string
+	matches []Match
+}
+
+func (w *walker) walk(v reflect.Value, path string, selectors []selector) {
+	if len(selectors) == 0 {
+		if path == "" {
+			path = "."
+		}
+		w.matches = append(w.matches, Match{Path: path, Value: v})
+		return
+	}
+
+	// pointers and interfaces are transparent, as when dumping, but values
+	// with a custom dumper are opaque as it might hide part of them, like
+	// the private keys
+	for {
+		if v.Kind() != reflect.Interface && hasCustomDumper(v.Type()) {
+			return
+		}
+		if v.Kind() != reflect.Ptr && v.Kind() != reflect.Interface {
+			break
+		}
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+
+	sel, rest := selectors[0], selectors[1:]
+	switch v.Kind() {
+	case reflect.Struct:
+		if sel.isIndex {
+			return
+		}
+		typ := v.Type()
+		if !v.CanAddr() && v.CanInterface() {
+			v = addressable(v).Elem()
+		}
+		for i := 0; i < v.NumField(); i++ {
+			field := typ.Field(i)
+			if field.PkgPath != "" && field.PkgPath != w.caller {
+				continue
+			}
+			if !sel.wildcard && field.Name != sel.name {
+				continue
+			}
+			w.walk(w.redact(field.Name, exposed(v.Field(i))), path+"."+field.Name, rest)
+		}
+
+	case reflect.Map:
+		keys, values := sortedMapEntries(v)
+		for i, k := range keys {
+			key := fmt.Sprint(k)
+			if !sel.wildcard && key != sel.name {
+				continue
+			}
+			value := values[i]
+			if k.Kind() == reflect.String || (k.Kind() == reflect.Interface && k.Elem().Kind() == reflect.String) {
+				value = w.redact(key, value)
+			}
+			w.walk(value, path+keyPath(k, key), rest)
+		}
+
+	case reflect.Array, reflect.Slice:
+		if sel.wildcard {
+			for i := 0; i < v.Len(); i++ {
+				w.walk(v.Index(i), fmt.Sprintf("%s[%d]", path, i), rest)
+			}
+		} else if sel.isIndex && sel.index >= 0 && sel.index < v.Len() {
+			w.walk(v.Index(sel.index), fmt.Sprintf("%s[%d]", path, sel.index), rest)
+		}
+	}
+}
+
+// redact replaces the value when its name is redacted
+func (w *walker) redact(name string, v reflect.Value) reflect.Value {
+	if w.opts.IsRedacted(name) {
+		return reflect.ValueOf(redactedValue)
+	}
+
+	return v
+}
+
+// keyPath returns the path step of a map key
+func keyPath(k reflect.Value, key string) string {
+	if k.Kind() == reflect.Interface {
+		k = k.Elem()
+	}
+
+	if k.Kind() == reflect.String {
+		if isIdentifier(key) {
+			return "." + key
+		}
+		return "[" + strconv.Quote(key) + "]"
+	}
+
+	return "[" + key + "]"
+}
+
+func isIdentifier(s string) bool {
+	if s == "" || s == "*" {
+		return false
+	}
+
+	for _, r := range s {
+		if r != '_' && r != '-' && !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Dump implements Dumpable.
+func (sel Selection) Dump(s State) {
+	if sel.Err != nil {
+		s.AddComment(fmt.Sprintf("invalid query: %s", sel.Err))
+		_, _ = s.Write([]byte("<invalid>"))
+		return
+	}
+
+	if len(sel.Matches) == 0 {
+		s.AddComment(fmt.Sprintf("no match for %s", sel.Query))
+		_, _ = s.Write([]byte("<none>"))
+		return
+	}
+
+	for _, m := range sel.Matches {
+		s.DumpStructField(m.Path, m.Value)
+	}
+	// added last to end up on the opening line
+	if len(sel.Matches) == 1 {
+		s.AddComment(fmt.Sprintf("1 match for %s", sel.Query))
+	} else {
+		s.AddComment(fmt.Sprintf("%d matches for %s", len(sel.Matches), sel.Query))
+	}
+}
@@ -0,0 +1,305 @@
+//go:build go1.21
+
+/*
+ * Copyright (c) 2021-present Fabien Potencier <fabien@symfony.com>
+ *
+ * This file is part of Symfony CLI project
+ *
+ * This program is free software: you can redistribute it and/or modify
+ * it under the terms of the GNU Affero General Public License as
+ * published by the Free Software Foundation, either version 3 of the
+ * License, or (at your option) any later version.
+ *
+ * This program is distributed in the hope that it will be useful,
+ * but WITHOUT ANY WARRANTY; without even the implied warranty of
+ * MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+ * GNU Affero General Public License for more details.
+ *
+ * You should have received a copy of the GNU Affero General Public License
+ * along with this program. If not, see <http://www.gnu.org/licenses/>.
+ */
+
+package dumper
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+// SlogHandler is a slog.Handler rendering the struct, map, array, and slice
+// attributes with the dumper. Values are dumped on a single line, unless
+// Options.Multiline is set.
+type SlogHandler struct {
+	opts Options
+
+	// when wrapping another handler
+	next slog.Handler
+
+	// when writing text
+	out    io.Writer
+	mu     *sync.Mutex
+	level  slog.Leveler
+	attrs  []textAttr
+	groups []string
+}
+
+// textAttr is an attribute rendered by the text handler
+type textAttr struct {
+	key   string
+	value string
+}
+
+// NewSlogHandler returns a handler passing the records to next, once their
+// struct, map, array, and slice attributes are dumped as strings.
+func NewSlogHandler(next slog.Handler, opts Options) *SlogHandler {
+	return &SlogHandler{next: next, opts: opts}
+}
+
+// NewSlogTextHandler returns a handler writing the records to out, one line
+// per record followed by the multi-line dumps if any. Records below level
+// are discarded; if level is nil, slog.LevelInfo is used.
+func NewSlogTextHandler(out io.Writer, level slog.Leveler, opts Options) *SlogHandler {
+	if level == nil {
+		level = slog.LevelInfo
+	}
+
+	return &SlogHandler{out: out, mu: &sync.Mutex{}, level: level, opts: opts}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *SlogHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	if h.next != nil {
+		return h.next.Enabled(ctx, level)
+	}
+
+	return level >= h.level.Level()
+}
+
+// Handle handles the record.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+	if h.next != nil {
+		record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+		r.Attrs(func(a slog.Attr) bool {
+			record.AddAttrs(h.convert(a))
+			return true
+		})
+
+		return h.next.Handle(ctx, record)
+	}
+
+	attrs := h.attrs
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = h.flatten(attrs, h.groups, a)
+		return true
+	})
+
+	buf := &bytes.Buffer{}
+	if !r.Time.IsZero() {
+		buf.WriteString(r.Time.Format("2006-01-02 15:04:05.000"))
+		buf.WriteByte(' ')
+	}
+	buf.WriteString(r.Level.String())
+	buf.WriteByte(' ')
+	buf.WriteString(r.Message)
+
+	var blocks []string
+	for _, a := range attrs {
+		if strings.Contains(a.value, "\n") {
+			blocks = append(blocks, a.key+"="+a.value)
+			continue
+		}
+		buf.WriteString(" " + a.key + "=" + a.value)
+	}
+	buf.WriteByte('\n')
+	for _, block := range blocks {
+		for _, line := range strings.Split(block, "\n") {
+			buf.WriteString("  " + line + "\n")
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := h.out.Write(buf.Bytes())
+
+	return err
+}
+
+// WithAttrs returns a handler whose records have the attributes.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	h2 := *h
+	if h.next != nil {
+		converted := make([]slog.Attr, len(attrs))
+		for i, a := range attrs {
+			converted[i] = h.convert(a)
+		}
+		h2.next = h.next.WithAttrs(converted)
+
+		return &h2
+	}
+
+	h2.attrs = append([]textAttr{}, h.attrs...)
+	for _, a := range attrs {
+		h2.attrs = h.flatten(h2.attrs, h.groups, a)
+	}
+
+	return &h2
+}
+
+// WithGroup returns a handler qualifying the attributes with the group.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	h2 := *h
+	if h.next != nil {
+		h2.next = h.next.WithGroup(name)
+	} else {
+		h2.groups = append(append([]string{}, h.groups...), name)
+	}
+
+	return &h2
+}
+
+// convert replaces the values to dump by their dump
+func (h *SlogHandler) convert(a slog.Attr) slog.Attr {
+	if d, ok := lazyDumpOf(a.Value); ok {
+		return slog.String(a.Key, h.dump(d.v))
+	}
+
+	a.Value = a.Value.Resolve()
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		group := a.Value.Group()
+		converted := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			converted[i] = h.convert(ga)
+		}
+		a.Value = slog.GroupValue(converted...)
+
+	case slog.KindAny:
+		if v := a.Value.Any(); isDumpedBySlogHandler(v) {
+			a.Value = slog.StringValue(h.dump(v))
+		}
+	}
+
+	return a
+}
+
+// flatten appends the rendered attribute to the list, groups being
+// flattened as dotted keys as done by slog.TextHandler
+func (h *SlogHandler) flatten(attrs []textAttr, groups []string, a slog.Attr) []textAttr {
+	key := func() string {
+		return strings.Join(append(append([]string{}, groups...), a.Key), ".")
+	}
+
+	if d, ok := lazyDumpOf(a.Value); ok {
+		return append(attrs, textAttr{key: key(), value: strings.TrimRight(h.dump(d.v), "\n")})
+	}
+
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return attrs
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindGroup:
+		if a.Key != "" {
+			groups = append(append([]string{}, groups...), a.Key)
+		}
+		for _, ga := range a.Value.Group() {
+			attrs = h.flatten(attrs, groups, ga)
+		}
+
+		return attrs
+
+	case slog.KindAny:
+		if v := a.Value.Any(); isDumpedBySlogHandler(v) {
+			return append(attrs, textAttr{key: key(), value: strings.TrimRight(h.dump(v), "\n")})
+		}
+	}
+
+	return append(attrs, textAttr{key: key(), value: quoteIfNeeded(a.Value)})
+}
+
+func (h *SlogHandler) dump(v interface{}) string {
+	if h.opts.Multiline {
+		return h.opts.Sdump(v)
+	}
+
+	return h.opts.sdumpCompact(v)
+}
+
+// isDumpedBySlogHandler checks whether the value is a struct, a map, an
+// array, or a slice, or a pointer to one of them. Errors are left to the
+// wrapped handler.
+func isDumpedBySlogHandler(v interface{}) bool {
+	if _, ok := v.(error); ok || v == nil {
+		return false
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr && !rv.IsNil() {
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Array, reflect.Slice:
+		return true
+	}
+
+	return false
+}
+
+// quoteIfNeeded quotes the strings holding spaces or special characters
+func quoteIfNeeded(v slog.Value) string {
+	str := v.String()
+	if str == "" {
+		return `""`
+	}
+
+	for _, r := range str {
+		if unicode.IsSpace(r) || r == '"' || r == '=' || !unicode.IsPrint(r) {
+			return strconv.Quote(str)
+		}
+	}
+
+	return str
+}
+
+// lazyDump is a slog.LogValuer dumping the value only when resolved
+type lazyDump struct {
+	v interface{}
+}
+
+// LogValue dumps the value on a single line.
+func (d lazyDump) LogValue() slog.Value {
+	return slog.StringValue(DefaultOptions.sdumpCompact(d.v))
+}
+
+func lazyDumpOf(v slog.Value) (lazyDump, bool) {
+	if v.Kind() != slog.KindLogValuer {
+		return lazyDump{}, false
+	}
+	d, ok := v.LogValuer().(lazyDump)
+
+	return d, ok
+}
+
+// Attr returns an attribute whose value is dumped only when the record is
+// handled, so not when the level of the record is disabled. A SlogHandler
+// dumps it with its own options.
+func Attr(key string, v interface{}) slog.Attr {
+	return slog.Any(key, lazyDump{v: v})
+}
@@ -20,7 +20,6 @@
 package dumper
 
 import (
-	"bytes"
 	"io"
 	"os"
 	"path/filepath"
@@ -60,17 +59,34 @@ func lastCaller() string {
 	return lastCaller
 }
 
-func fdump(out io.Writer, styles map[string]string, values ...interface{}) {
+func fdump(out io.Writer, opts Options, styles map[string]string, values ...interface{}) {
+	if opts.singleLine {
+		out = &compactWriter{w: out}
+	}
+	caller := opts.caller
+	if caller == "" {
+		caller = lastCaller()
+	}
+
 	for i, value := range values {
 		if i > 0 {
 			_, _ = out.Write([]byte("\n"))
 		}
+		if opts.Select != "" {
+			value = selectPath(reflect.ValueOf(value), opts.Select, opts, caller)
+		}
 		state := state{
+			opts:       opts,
 			styles:     styles,
 			pointers:   mapPointers(reflect.ValueOf(value)),
 			comments:   []string{},
 			w:          out,
-			lastCaller: lastCaller(),
+			lastCaller: caller,
+
+			excludedPaths: opts.excludedPaths(),
+
+			forceNewLines: opts.Multiline,
+			noComments:    opts.noComments,
 		}
 		state.Dump(value)
 	}
@@ -78,24 +94,17 @@ func fdump(out io.Writer, styles map[string]string, values ...interface{}) {
 
 // Fdump prints to the writer the value with indentation.
 func Fdump(out io.Writer, values ...interface{}) {
-	fdump(out, defaultStyles, values...)
-	_, _ = out.Write([]byte("\n"))
+	DefaultOptions.Fdump(out, values...)
 }
 
 // Sdump dumps the values into a string with indentation.
 func Sdump(values ...interface{}) string {
-	buf := &bytes.Buffer{}
-	buf.Reset()
-
-	fdump(buf, defaultStyles, values...)
-
-	return buf.String()
+	return DefaultOptions.Sdump(values...)
 }
 
 // FdumpColor prints to the writer the value with indentation and color.
 func FdumpColor(out io.Writer, values ...interface{}) {
-	fdump(out, colorStyles, values...)
-	_, _ = out.Write([]byte("\n"))
+	DefaultOptions.FdumpColor(out, values...)
 }
 
 // Prints to given output the value(s) that is (are) passed as the argument(s)